@@ -0,0 +1,197 @@
+package logs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// queuedLogger 给一个 Logger 加上自己的队列和 goroutine，慢的 Logger（如网络）不会拖慢其他 Logger
+type queuedLogger struct {
+	Logger
+	al   *AppLogger
+	name string
+	drop bool // 队列满时丢弃而不是等待
+
+	lock   sync.RWMutex
+	closed bool
+	queue  chan queuedItem
+	done   chan struct{}
+}
+
+// queuedItem 队列里的一条log，或者 flush 为非 nil 时表示一次 Flush：写完之前的log后关闭 flush
+type queuedItem struct {
+	r         Record
+	formatted []byte
+	flush     chan struct{}
+}
+
+// SetAdapterQueue 让名为 name 的 Logger 使用自己的长度为 size 的队列和 goroutine，AppLogger 只把log放进队列就返回；
+// 队列满时 drop 为 true 则丢弃并通过 error handler 报告，否则等待。Flush 和 Close 会等队列写完
+func (al *AppLogger) SetAdapterQueue(name string, size int, drop bool) error {
+	if size <= 0 {
+		return fmt.Errorf("logs: invalid adapter queue size %d", size)
+	}
+	for _, l := range al.outputs {
+		if l.name != name {
+			continue
+		}
+		if _, ok := l.Logger.(*queuedLogger); ok {
+			return fmt.Errorf("logs: adapter %q already has a queue", name)
+		}
+		q := &queuedLogger{
+			Logger: l.Logger,
+			al:     al,
+			name:   name,
+			drop:   drop,
+			queue:  make(chan queuedItem, size),
+			done:   make(chan struct{}),
+		}
+		go q.run()
+		l.Logger = q
+		return nil
+	}
+	return fmt.Errorf("logs: unknown adaptername %q", name)
+}
+
+var errAdapterQueueClosed = errors.New("logs: adapter queue closed")
+
+// enqueue 把 r 放进队列
+func (q *queuedLogger) enqueue(r *Record, formatted []byte) error {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	if q.closed {
+		return errAdapterQueueClosed
+	}
+	item := queuedItem{r: *r, formatted: formatted}
+	if !q.drop {
+		q.queue <- item
+		return nil
+	}
+	select {
+	case q.queue <- item:
+		return nil
+	default:
+		return errors.New("logs: adapter queue full, message dropped")
+	}
+}
+
+func (q *queuedLogger) run() {
+	defer close(q.done)
+	for item := range q.queue {
+		if item.flush != nil {
+			close(item.flush)
+			continue
+		}
+		err := safeWriteRecord(q.Logger, &item.r, item.formatted)
+		if err == errBreakerOpen {
+			q.al.writeFallback(&item.r)
+			continue
+		}
+		if err != nil {
+			q.al.setLastError(q.name, err)
+			q.al.reportError(q.name, err)
+		}
+	}
+}
+
+// WriteMsg put message into the queue.
+func (q *queuedLogger) WriteMsg(when time.Time, msg string, level int) error {
+	return q.enqueue(&Record{When: when, Level: level, Msg: msg, text: msg}, nil)
+}
+
+// WriteRecord put record into the queue.
+func (q *queuedLogger) WriteRecord(r *Record) error {
+	return q.enqueue(r, nil)
+}
+
+// GetLevel return the level of the underlying logger.
+func (q *queuedLogger) GetLevel() int {
+	if lw, ok := q.Logger.(LevelWriter); ok {
+		return lw.GetLevel()
+	}
+	return LevelDebug
+}
+
+// SetLevel set the level of the underlying logger.
+func (q *queuedLogger) SetLevel(level int) {
+	if lw, ok := q.Logger.(LevelWriter); ok {
+		lw.SetLevel(level)
+	}
+}
+
+// wait 等队列里已有的log写完
+func (q *queuedLogger) wait() {
+	q.lock.RLock()
+	if q.closed {
+		q.lock.RUnlock()
+		return
+	}
+	ack := make(chan struct{})
+	q.queue <- queuedItem{flush: ack}
+	q.lock.RUnlock()
+	<-ack
+}
+
+// Flush wait for the queue to drain, then flush the underlying logger.
+func (q *queuedLogger) Flush() {
+	q.wait()
+	q.Logger.Flush()
+}
+
+// Reopen wait for the queue to drain, then reopen the underlying logger.
+func (q *queuedLogger) Reopen() error {
+	q.wait()
+	if r, ok := q.Logger.(Reopener); ok {
+		return r.Reopen()
+	}
+	return nil
+}
+
+// Rotate wait for the queue to drain, then rotate the underlying logger.
+func (q *queuedLogger) Rotate() error {
+	q.wait()
+	if r, ok := q.Logger.(Rotator); ok {
+		return r.Rotate()
+	}
+	return nil
+}
+
+// SetRotateNamer set the rotate namer of the underlying logger if it supports rotation.
+func (q *queuedLogger) SetRotateNamer(fn RotateNamer) {
+	if r, ok := q.Logger.(interface{ SetRotateNamer(fn RotateNamer) }); ok {
+		r.SetRotateNamer(fn)
+	}
+}
+
+// Destroy write out the queue, stop the goroutine and destroy the underlying logger.
+func (q *queuedLogger) Destroy() {
+	q.lock.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.queue)
+	}
+	q.lock.Unlock()
+	<-q.done
+	q.Logger.Destroy()
+}
+
+func (q *queuedLogger) takeErr() error {
+	if b, ok := q.Logger.(batchErrorer); ok {
+		return b.takeErr()
+	}
+	return nil
+}
+
+func (q *queuedLogger) setPalette(p *palette) {
+	if t, ok := q.Logger.(themedLogger); ok {
+		t.setPalette(p)
+	}
+}
+
+// MarshalJSON 使用底层 Logger 的配置，见 ConfigJSON
+func (q *queuedLogger) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.Logger)
+}
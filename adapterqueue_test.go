@@ -0,0 +1,89 @@
+package logs
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 慢的 Logger 有自己的队列时不拖慢其他 Logger
+func TestSetAdapterQueue(t *testing.T) {
+	al, fast := newTestLogger(t)
+	slow := &gateLogger{gate: make(chan struct{})}
+	al.AddAdapter("net", slow)
+	if err := al.SetAdapterQueue("net", 2, true); err != nil {
+		t.Fatal(err)
+	}
+	var dropped int32
+	al.SetErrorHandler(func(name string, err error) {
+		if name == "net" && strings.Contains(err.Error(), "dropped") {
+			atomic.AddInt32(&dropped, 1)
+		}
+	})
+	al.Async()
+
+	within(t, time.Second, "fast adapter", func() {
+		for i := 0; i < 10; i++ {
+			al.Info("msg %d", i)
+		}
+		for len(fast.all()) < 10 {
+			time.Sleep(time.Millisecond)
+		}
+	})
+	close(slow.gate)
+	al.Close()
+
+	lines := slow.lines()
+	if len(lines) < 2 || len(lines) > 3 {
+		t.Errorf("slow adapter got %q", lines)
+	}
+	for i, l := range lines {
+		if want := fmt.Sprint("[I]  msg ", i); l != want {
+			t.Errorf("slow line %d = %q, want %q", i, l, want)
+		}
+	}
+	if n := atomic.LoadInt32(&dropped); int(n) != 10-len(lines) {
+		t.Errorf("%d drops reported, %d written", n, len(lines))
+	}
+}
+
+// 不丢弃时 Flush 和 Close 等队列写完
+func TestAdapterQueueBlocking(t *testing.T) {
+	al, _ := newTestLogger(t)
+	slow := &slowLogger{delay: time.Millisecond}
+	al.AddAdapter("slow", slow)
+	if err := al.SetAdapterQueue("slow", 1, false); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		al.Info("msg %d", i)
+	}
+	al.Flush()
+	if n := len(slow.all()); n != 20 {
+		t.Errorf("%d written after Flush", n)
+	}
+	al.Info("last")
+	al.Close()
+	if n := len(slow.all()); n != 21 {
+		t.Errorf("%d written after Close", n)
+	}
+}
+
+func TestSetAdapterQueueErrors(t *testing.T) {
+	al, _ := newTestLogger(t)
+	defer al.Close()
+	if err := al.SetAdapterQueue("capture", 0, false); err == nil {
+		t.Error("accepted size 0")
+	}
+	if err := al.SetAdapterQueue("nope", 1, false); err == nil {
+		t.Error("accepted an unknown adapter")
+	}
+	if err := al.SetAdapterQueue("capture", 1, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := al.SetAdapterQueue("capture", 1, false); err == nil {
+		t.Error("queued an adapter twice")
+	}
+}
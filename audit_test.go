@@ -0,0 +1,92 @@
+package logs
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var errDiskFull = errors.New("no space left on device")
+
+// newFullDiskLogger 返回只有一个写入总是失败的 file adapter 的 AppLogger
+func newFullDiskLogger(t *testing.T) *AppLogger {
+	t.Helper()
+	inTempDir(t)
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	f := NewFile().(*fileWriter)
+	f.lg.writer = errWriter{errDiskFull}
+	if err := al.AddAdapter(AdapterFile, f); err != nil {
+		t.Fatal(err)
+	}
+	al.SetErrorHandler(func(string, error) {})
+	return al
+}
+
+func TestAuditModeCloseErr(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		al := newFullDiskLogger(t)
+		al.SetAuditMode(true)
+		if async {
+			al.Async()
+		}
+		al.Info("first")
+		al.Info("second")
+		err := al.CloseErr()
+		if err == nil || !strings.Contains(err.Error(), errDiskFull.Error()) {
+			t.Errorf("async %v: CloseErr() = %v", async, err)
+		}
+	}
+}
+
+func TestAuditModeOff(t *testing.T) {
+	al := newFullDiskLogger(t)
+	al.Info("msg")
+	if err := al.CloseErr(); err != nil {
+		t.Errorf("CloseErr() = %v without audit mode", err)
+	}
+}
+
+// 审计模式下队列满时不会因为 SetEnqueueTimeout 丢弃log
+func TestAuditModeNoDrops(t *testing.T) {
+	al, c := newTestLogger(t)
+	al.SetAuditMode(true)
+	al.SetEnqueueTimeout(time.Nanosecond)
+	al.Async(1)
+	for i := 0; i < 500; i++ {
+		al.Info("msg %d", i)
+	}
+	if err := al.CloseErr(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(c.all()); n != 500 || al.DroppedCount() != 0 {
+		t.Errorf("%d written, %d dropped", n, al.DroppedCount())
+	}
+}
+
+// 写入失败的 file adapter 也会更新 LastError、写到 fallback 并触发断路器
+func TestFailingFileAdapter(t *testing.T) {
+	al := newFullDiskLogger(t)
+	defer al.Close()
+	var fallback bytes.Buffer
+	al.SetFallback(&fallback)
+
+	al.Info("lost")
+	if _, name, err := al.LastError(); name != AdapterFile || err != errDiskFull {
+		t.Errorf("LastError() = %q, %v", name, err)
+	}
+	if al.FallbackCount() != 1 || !strings.Contains(fallback.String(), "lost") {
+		t.Errorf("fallback got %d: %q", al.FallbackCount(), fallback.String())
+	}
+
+	if err := al.SetAdapterBreaker(AdapterFile, 2, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	al.Info("one")
+	al.Info("two")
+	if state, _ := al.AdapterBreakerState(AdapterFile); state != BreakerOpen {
+		t.Errorf("breaker is %s after 2 failures", state)
+	}
+}
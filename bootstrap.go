@@ -0,0 +1,54 @@
+package logs
+
+import "sync"
+
+// bootstrapBuffer 启动阶段还没有添加 Logger 之前写的log
+type bootstrapBuffer struct {
+	sync.Mutex
+	max     int
+	records []Record
+}
+
+// EnableBootstrapBuffer 保留之后写出的前 n 条log，下一次 AddLogger 或 AddWriter 时先把它们补写到新的 Logger，
+// 然后停止保留，用来留住配置 Logger 之前的启动日志。n <= 0 时关闭并丢弃已保留的log
+func (al *AppLogger) EnableBootstrapBuffer(n int) {
+	b := &al.bootstrap
+	b.Lock()
+	defer b.Unlock()
+	if n <= 0 {
+		b.max, b.records = 0, nil
+		return
+	}
+	b.max = n
+	if len(b.records) > n {
+		b.records = b.records[:n]
+	}
+}
+
+// add 在开启时保留 r 的一份拷贝
+func (b *bootstrapBuffer) add(r *Record) {
+	b.Lock()
+	defer b.Unlock()
+	if len(b.records) < b.max {
+		b.records = append(b.records, *r)
+	}
+}
+
+// take 取出保留的log并关闭
+func (b *bootstrapBuffer) take() []Record {
+	b.Lock()
+	defer b.Unlock()
+	records := b.records
+	b.max, b.records = 0, nil
+	return records
+}
+
+// replayBootstrap 把保留的启动日志补写到刚添加的 lg
+func (al *AppLogger) replayBootstrap(lg Logger) {
+	for _, r := range al.bootstrap.take() {
+		if err := writeRecord(lg, &r, al.format(&r)); err != nil {
+			al.reportError("bootstrap", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package logs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBootstrapBuffer(t *testing.T) {
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	defer al.Close()
+	al.EnableBootstrapBuffer(2)
+	al.Info("starting")
+	al.Warn("config missing, using defaults")
+	al.Info("over the limit")
+
+	c := &captureLogger{}
+	al.AddAdapter("capture", c)
+	al.Info("configured")
+	// 只补写到第一个添加的 Logger
+	late := &captureLogger{}
+	al.AddAdapter("late", late)
+
+	lines := c.lines()
+	want := []string{"starting", "config missing, using defaults", "configured"}
+	if len(lines) != len(want) {
+		t.Fatalf("got %q", lines)
+	}
+	for i, w := range want {
+		if !strings.HasSuffix(lines[i], w) {
+			t.Errorf("line %d = %q, want suffix %q", i, lines[i], w)
+		}
+	}
+	if n := len(late.all()); n != 0 {
+		t.Errorf("replayed %d records to a second Logger", n)
+	}
+}
+
+func TestBootstrapBufferDisabled(t *testing.T) {
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	defer al.Close()
+	al.EnableBootstrapBuffer(10)
+	al.Info("dropped")
+	al.EnableBootstrapBuffer(0)
+	c := &captureLogger{}
+	al.AddAdapter("capture", c)
+	if n := len(c.all()); n != 0 {
+		t.Errorf("replayed %d records after disabling", n)
+	}
+}
@@ -0,0 +1,193 @@
+package logs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 断路器的状态，由 AdapterBreakerState 返回
+const (
+	BreakerClosed   = "closed"    // 正常写入
+	BreakerOpen     = "open"      // 连续失败太多次，暂停写入，log改写到 fallback
+	BreakerHalfOpen = "half-open" // 冷却时间已过，正在用一条log试探
+)
+
+// breakerLogger 给一个 Logger 加上断路器：连续失败 failures 次之后 cooldown 时间内不再尝试写入，
+// 之后用一条log试探，成功就恢复，失败就再等一个 cooldown。用于网络类的 Logger，避免故障期间每条log都等连接超时
+type breakerLogger struct {
+	Logger
+	failures int
+	cooldown time.Duration
+
+	lock     sync.Mutex
+	state    string
+	failed   int // 连续失败的次数
+	openedAt time.Time
+}
+
+// errBreakerOpen 断路器打开时 breakerLogger 返回，这条log改写到 fallback，不作为写入失败报告
+var errBreakerOpen = errors.New("logs: adapter circuit breaker is open")
+
+// SetAdapterBreaker 给名为 name 的 Logger 加上断路器：连续写入失败 failures 次之后，cooldown 时间内的log不再交给它，
+// 而是写到 SetFallback 设置的 fallback；冷却之后用一条log试探，成功则恢复正常。状态由 AdapterBreakerState 查询
+func (al *AppLogger) SetAdapterBreaker(name string, failures int, cooldown time.Duration) error {
+	if failures <= 0 || cooldown <= 0 {
+		return fmt.Errorf("logs: invalid circuit breaker %d failures, cooldown %v", failures, cooldown)
+	}
+	for _, l := range al.outputs {
+		if l.name != name {
+			continue
+		}
+		// 有自己的队列时加在队列里面，才能看到写入的结果
+		target := &l.Logger
+		if q, ok := l.Logger.(*queuedLogger); ok {
+			target = &q.Logger
+		}
+		if _, ok := (*target).(*breakerLogger); ok {
+			return fmt.Errorf("logs: adapter %q already has a circuit breaker", name)
+		}
+		*target = &breakerLogger{
+			Logger:   *target,
+			failures: failures,
+			cooldown: cooldown,
+			state:    BreakerClosed,
+		}
+		return nil
+	}
+	return fmt.Errorf("logs: unknown adaptername %q", name)
+}
+
+// AdapterBreakerState 返回名为 name 的 Logger 的断路器状态：BreakerClosed、BreakerOpen 或 BreakerHalfOpen，
+// 没有用 SetAdapterBreaker 加上断路器时返回错误
+func (al *AppLogger) AdapterBreakerState(name string) (string, error) {
+	for _, l := range al.outputs {
+		if l.name != name {
+			continue
+		}
+		lg := l.Logger
+		if q, ok := lg.(*queuedLogger); ok {
+			lg = q.Logger
+		}
+		b, ok := lg.(*breakerLogger)
+		if !ok {
+			return "", fmt.Errorf("logs: adapter %q has no circuit breaker", name)
+		}
+		b.lock.Lock()
+		defer b.lock.Unlock()
+		return b.state, nil
+	}
+	return "", fmt.Errorf("logs: unknown adaptername %q", name)
+}
+
+// write 断路器允许时把 r 写到底层的 Logger，否则返回 errBreakerOpen
+func (b *breakerLogger) write(r *Record, formatted []byte) error {
+	if !b.allow(time.Now()) {
+		return errBreakerOpen
+	}
+	err := safeWriteRecord(b.Logger, r, formatted)
+	b.result(err)
+	return err
+}
+
+// allow 返回这次是否写入，冷却时间过后只放过一条用于试探
+func (b *breakerLogger) allow(now time.Time) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	switch b.state {
+	case BreakerClosed:
+		return true
+	case BreakerOpen:
+		if now.Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		return true
+	}
+	// 正在试探，其他的log不等结果
+	return false
+}
+
+// result 记录一次写入的结果
+func (b *breakerLogger) result(err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if err == nil {
+		b.state = BreakerClosed
+		b.failed = 0
+		return
+	}
+	b.failed++
+	if b.state == BreakerHalfOpen || b.failed >= b.failures {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// WriteMsg write message through the circuit breaker.
+func (b *breakerLogger) WriteMsg(when time.Time, msg string, level int) error {
+	return b.write(&Record{When: when, Level: level, Msg: msg, text: msg}, nil)
+}
+
+// WriteRecord write record through the circuit breaker.
+func (b *breakerLogger) WriteRecord(r *Record) error {
+	return b.write(r, nil)
+}
+
+// GetLevel return the level of the underlying logger.
+func (b *breakerLogger) GetLevel() int {
+	if lw, ok := b.Logger.(LevelWriter); ok {
+		return lw.GetLevel()
+	}
+	return LevelDebug
+}
+
+// SetLevel set the level of the underlying logger.
+func (b *breakerLogger) SetLevel(level int) {
+	if lw, ok := b.Logger.(LevelWriter); ok {
+		lw.SetLevel(level)
+	}
+}
+
+// Reopen reopen the underlying logger.
+func (b *breakerLogger) Reopen() error {
+	if r, ok := b.Logger.(Reopener); ok {
+		return r.Reopen()
+	}
+	return nil
+}
+
+// Rotate rotate the underlying logger.
+func (b *breakerLogger) Rotate() error {
+	if r, ok := b.Logger.(Rotator); ok {
+		return r.Rotate()
+	}
+	return nil
+}
+
+// SetRotateNamer set the rotate namer of the underlying logger if it supports rotation.
+func (b *breakerLogger) SetRotateNamer(fn RotateNamer) {
+	if r, ok := b.Logger.(interface{ SetRotateNamer(fn RotateNamer) }); ok {
+		r.SetRotateNamer(fn)
+	}
+}
+
+func (b *breakerLogger) takeErr() error {
+	if be, ok := b.Logger.(batchErrorer); ok {
+		return be.takeErr()
+	}
+	return nil
+}
+
+func (b *breakerLogger) setPalette(p *palette) {
+	if t, ok := b.Logger.(themedLogger); ok {
+		t.setPalette(p)
+	}
+}
+
+// MarshalJSON 使用底层 Logger 的配置，见 ConfigJSON
+func (b *breakerLogger) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.Logger)
+}
@@ -0,0 +1,123 @@
+package logs
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// attemptLogger 记录写入的次数，包括失败的
+type attemptLogger struct {
+	captureLogger
+	attempts int32
+}
+
+func (a *attemptLogger) WriteRecord(r *Record) error {
+	atomic.AddInt32(&a.attempts, 1)
+	return a.captureLogger.WriteRecord(r)
+}
+
+func TestAdapterBreaker(t *testing.T) {
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	defer al.Close()
+	net := &attemptLogger{}
+	al.AddAdapter("net", net)
+	var fallback bytes.Buffer
+	al.SetFallback(&fallback)
+	const cooldown = 50 * time.Millisecond
+	if err := al.SetAdapterBreaker("net", 3, cooldown); err != nil {
+		t.Fatal(err)
+	}
+	state := func(want string, attempts int32) {
+		t.Helper()
+		if got, err := al.AdapterBreakerState("net"); got != want || err != nil {
+			t.Errorf("state %q, %v, want %q", got, err, want)
+		}
+		if n := atomic.LoadInt32(&net.attempts); n != attempts {
+			t.Errorf("%d write attempts, want %d", n, attempts)
+		}
+	}
+	state(BreakerClosed, 0)
+
+	net.setErr(errors.New("connection refused"))
+	for i := 0; i < 3; i++ {
+		al.Info("failing %d", i)
+	}
+	state(BreakerOpen, 3)
+	// 打开之后不再尝试写入，log改写到 fallback
+	for i := 0; i < 5; i++ {
+		al.Info("rerouted %d", i)
+	}
+	state(BreakerOpen, 3)
+	if n := al.FallbackCount(); n != 8 || !strings.Contains(fallback.String(), "rerouted 4") {
+		t.Errorf("fallback got %d: %q", n, fallback.String())
+	}
+
+	// 冷却之后试探失败，重新打开
+	time.Sleep(cooldown + 10*time.Millisecond)
+	al.Info("probe fails")
+	state(BreakerOpen, 4)
+
+	net.setErr(nil)
+	time.Sleep(cooldown + 10*time.Millisecond)
+	al.Info("probe succeeds")
+	al.Info("back to normal")
+	state(BreakerClosed, 6)
+	if got := strings.Join(net.lines(), "|"); got != "[I]  probe succeeds|[I]  back to normal" {
+		t.Errorf("net got %q", got)
+	}
+}
+
+func TestSetAdapterBreakerErrors(t *testing.T) {
+	al, _ := newTestLogger(t)
+	defer al.Close()
+	if err := al.SetAdapterBreaker("capture", 0, time.Second); err == nil {
+		t.Error("accepted 0 failures")
+	}
+	if err := al.SetAdapterBreaker("capture", 1, 0); err == nil {
+		t.Error("accepted no cooldown")
+	}
+	if err := al.SetAdapterBreaker("nope", 1, time.Second); err == nil {
+		t.Error("accepted an unknown adapter")
+	}
+	if _, err := al.AdapterBreakerState("capture"); err == nil {
+		t.Error("state of an adapter without a breaker")
+	}
+	if err := al.SetAdapterBreaker("capture", 1, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := al.SetAdapterBreaker("capture", 1, time.Second); err == nil {
+		t.Error("added a second breaker")
+	}
+}
+
+// 加了断路器的 Logger 同样可以设置 RotateNamer
+func TestAdapterBreakerRotateNamer(t *testing.T) {
+	inTempDir(t)
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	defer al.Close()
+	if err := al.AddLogger(AdapterFile, `{"filename":"app.log","color":false}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := al.SetAdapterBreaker(AdapterFile, 1, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	err := al.SetRotateNamer(AdapterFile, func(base string, index int, when time.Time) string {
+		return "archive-" + base
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	al.Info("first")
+	if err := al.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, "archive-app.log"); !strings.HasSuffix(got, "first\n") {
+		t.Errorf("archive-app.log got %q", got)
+	}
+}
@@ -0,0 +1,99 @@
+package logs
+
+import "sync/atomic"
+
+// Child 带着一组固定字段的子 logger，可以单独设置级别，如只给一个请求打开 Debug，不影响父 logger 和其他子 logger。
+// 和 WithFields 返回的 Entry 不同，Child 可以保存下来反复使用，也可以在多个 goroutine 里同时使用
+type Child struct {
+	al     *AppLogger
+	parent *Child
+	fields []Field
+	level  int32 // 级别 + 1，0 表示没有设置，使用父 logger 的级别
+}
+
+// Child 返回带着 fields 的子 logger，没有单独设置级别时使用 al 的级别
+func (al *AppLogger) Child(fields ...Field) *Child {
+	return &Child{al: al, fields: append([]Field(nil), fields...)}
+}
+
+// Child 返回在 c 的字段后面再加上 fields 的子 logger，没有单独设置级别时使用 c 的级别
+func (c *Child) Child(fields ...Field) *Child {
+	merged := make([]Field, 0, len(c.fields)+len(fields))
+	merged = append(append(merged, c.fields...), fields...)
+	return &Child{al: c.al, parent: c, fields: merged}
+}
+
+// SetLevel 设置 c 的级别，level 及更严重级别的log会被写出
+func (c *Child) SetLevel(level int) {
+	atomic.StoreInt32(&c.level, int32(level+1))
+}
+
+// ClearLevel 去掉 c 的级别，重新使用父 logger 的级别
+func (c *Child) ClearLevel() {
+	atomic.StoreInt32(&c.level, 0)
+}
+
+// Enabled 返回 level 级别的log是否会被写出：使用最近一个设置了级别的子 logger 的级别，不受全局和模块级别影响；
+// 都没有设置时同 AppLogger.Enabled，设置了模块级别时模块级别优先
+func (c *Child) Enabled(level int) bool {
+	if l, ok := c.ownLevel(); ok {
+		return level <= l
+	}
+	return c.al.Enabled(level)
+}
+
+// ownLevel 返回最近一个设置了级别的子 logger 的级别，ok 为 false 表示都没有设置
+func (c *Child) ownLevel() (level int, ok bool) {
+	for p := c; p != nil; p = p.parent {
+		if l := atomic.LoadInt32(&p.level); l != 0 {
+			return int(l) - 1, true
+		}
+	}
+	return 0, false
+}
+
+// write 写出一条log，只能由 Info 这一类函数直接调用；设置了级别时已经由 Enabled 判断过，不再按模块级别检查
+func (c *Child) write(level int, fields []Field, format string, v []interface{}) {
+	_, own := c.ownLevel()
+	c.al.output(1, !own, level, fields, format, v)
+}
+
+// Log 同 AppLogger.Log，字段放在 fields 前面
+func (c *Child) Log(level int, msg string, fields ...Field) {
+	if !c.Enabled(level) {
+		return
+	}
+	c.write(level, append(c.fields[:len(c.fields):len(c.fields)], fields...), msg, nil)
+}
+
+func (c *Child) Info(format string, v ...interface{}) {
+	if !c.Enabled(LevelInfo) {
+		return
+	}
+	c.al.guardFormat(format, v)
+	c.write(LevelInfo, c.fields, format, v)
+}
+
+func (c *Child) Warn(format string, v ...interface{}) {
+	if !c.Enabled(LevelWarning) {
+		return
+	}
+	c.al.guardFormat(format, v)
+	c.write(LevelWarning, c.fields, format, v)
+}
+
+func (c *Child) Debug(format string, v ...interface{}) {
+	if !c.Enabled(LevelDebug) {
+		return
+	}
+	c.al.guardFormat(format, v)
+	c.write(LevelDebug, c.fields, format, v)
+}
+
+func (c *Child) Error(format string, v ...interface{}) {
+	if !c.Enabled(LevelError) {
+		return
+	}
+	c.al.guardFormat(format, v)
+	c.write(LevelError, c.fields, format, v)
+}
@@ -0,0 +1,74 @@
+package logs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChildLevel(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.SetLevel(LevelInfo)
+	parent := al.Child(String("req", "1"))
+	child := parent.Child(String("user", "u"))
+
+	child.Debug("hidden")
+	parent.SetLevel(LevelDebug)
+	child.Debug("inherited %d", 1)
+	child.SetLevel(LevelError)
+	child.Info("hidden")
+	child.ClearLevel()
+	child.Info("cleared")
+	al.Debug("hidden")
+
+	lines := c.lines()
+	if len(lines) != 2 || !strings.Contains(lines[0], "inherited 1") || !strings.Contains(lines[1], "cleared") {
+		t.Fatalf("got %q", lines)
+	}
+	if f := c.all()[0].Fields; f["req"] != "1" || f["user"] != "u" {
+		t.Errorf("fields %v", f)
+	}
+}
+
+// 单独设置的级别不受模块级别影响，没有设置级别的 Child 按模块级别过滤
+func TestChildLevelWithModuleLevels(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.SetLevel(LevelInfo)
+	al.SetModuleLevel("example.com/other", LevelDebug)
+	al.SetModuleLevel("senkasng/logs", LevelWarning)
+
+	raised := al.Child()
+	raised.SetLevel(LevelDebug)
+	raised.Debug("raised debug")
+	raised.Log(LevelInfo, "raised log")
+
+	plain := al.Child()
+	plain.Info("plain info")
+	plain.Warn("plain warn")
+
+	lines := c.lines()
+	for _, want := range []string{"raised debug", "raised log", "plain warn"} {
+		if !contains(lines, want) {
+			t.Errorf("missing %q in %q", want, lines)
+		}
+	}
+	if contains(lines, "plain info") {
+		t.Errorf("module level ignored: %q", lines)
+	}
+}
+
+func TestChildCaller(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.EnableFuncCallDepth(true)
+	child := al.Child()
+	child.SetLevel(LevelDebug)
+	child.Info("a")
+	child.Log(LevelInfo, "b")
+	for _, r := range c.all() {
+		if r.File != "child_test.go" {
+			t.Errorf("%q reported from %s:%d", r.Msg, r.File, r.Line)
+		}
+	}
+}
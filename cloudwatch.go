@@ -0,0 +1,182 @@
+package logs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// AdapterCloudWatch 写到 AWS CloudWatch Logs 的 log group/stream
+const AdapterCloudWatch = "cloudwatch"
+
+// PutLogEvents 一个批次的限制
+const (
+	cloudWatchMaxBatchEvents = 10000
+	cloudWatchMaxBatchBytes  = 1048576
+	cloudWatchEventOverhead  = 26 // 每条 event 额外计算的字节数
+)
+
+// CloudWatchEvent 一条 CloudWatch log event，Timestamp 为毫秒时间戳
+type CloudWatchEvent struct {
+	Timestamp int64
+	Message   string
+}
+
+// CloudWatchClient 发送 log event 的客户端，由使用者用 AWS SDK 实现，避免本包直接依赖 SDK；
+// sequenceToken 为 nil 表示还没有 token，返回下一次调用要用的 token
+type CloudWatchClient interface {
+	PutLogEvents(group, stream string, events []CloudWatchEvent, sequenceToken *string) (nextSequenceToken *string, err error)
+}
+
+// CloudWatchSequenceTokenError 客户端在 sequence token 失效时返回这个错误，并带上服务端期望的 token，
+// adapter 会用 ExpectedToken 重发一次
+type CloudWatchSequenceTokenError struct {
+	ExpectedToken *string
+}
+
+func (e *CloudWatchSequenceTokenError) Error() string {
+	return "logs: invalid cloudwatch sequence token"
+}
+
+var (
+	cloudWatchClientLock sync.Mutex
+	cloudWatchClient     CloudWatchClient
+)
+
+// SetCloudWatchClient 设置之后通过 AddLogger 创建的 cloudwatch adapter 使用的客户端；
+// 多个 AppLogger 要用不同的客户端时用 NewCloudWatchAdapter
+func SetCloudWatchClient(c CloudWatchClient) {
+	cloudWatchClientLock.Lock()
+	cloudWatchClient = c
+	cloudWatchClientLock.Unlock()
+}
+
+// cloudWatchWriter 把 log 攒成批次后用 PutLogEvents 发送，Flush 时强制发送
+type cloudWatchWriter struct {
+	sync.Mutex
+	client     CloudWatchClient
+	token      *string
+	events     []CloudWatchEvent
+	batchBytes int
+	err        error // 发送失败的错误，下一次 WriteMsg 时返回，Flush 之后由 AppLogger 通过 takeErr 取出
+
+	Group  string       `json:"group"`
+	Stream string       `json:"stream"`
+	Level  adapterLevel `json:"level"`
+}
+
+// NewCloudWatch create a cloudwatch writer using the client set by SetCloudWatchClient.
+func NewCloudWatch() Logger {
+	cloudWatchClientLock.Lock()
+	defer cloudWatchClientLock.Unlock()
+	return NewCloudWatchAdapter(cloudWatchClient)
+}
+
+// NewCloudWatchAdapter 返回使用 c 发送的 cloudwatch adapter，不受 SetCloudWatchClient 影响；
+// 先用 Init 设置 group 和 stream，配置同 AddLogger，再用 AddAdapter 添加
+func NewCloudWatchAdapter(c CloudWatchClient) Logger {
+	return &cloudWatchWriter{
+		client: c,
+		Level:  LevelDebug,
+	}
+}
+
+// Init init cloudwatch writer.
+// jsonConfig like '{"group":"app","stream":"web1","level":2}'.
+func (c *cloudWatchWriter) Init(jsonConfig string) error {
+	if len(jsonConfig) > 0 {
+		if err := json.Unmarshal([]byte(jsonConfig), c); err != nil {
+			return err
+		}
+	}
+	return c.checkConfig()
+}
+
+// checkConfig 检查客户端和配置的取值
+func (c *cloudWatchWriter) checkConfig() error {
+	if c.client == nil {
+		return errors.New("logs: cloudwatch client is nil (forgotten SetCloudWatchClient?)")
+	}
+	if c.Group == "" || c.Stream == "" {
+		return errors.New("logs: cloudwatch group and stream are required")
+	}
+	return nil
+}
+
+// WriteMsg add message to the current batch, sending it when a batch limit is reached.
+func (c *cloudWatchWriter) WriteMsg(when time.Time, msg string, level int) error {
+	if level > c.Level.get() {
+		return nil
+	}
+	c.Lock()
+	defer c.Unlock()
+
+	size := len(msg) + cloudWatchEventOverhead
+	if len(c.events) >= cloudWatchMaxBatchEvents || c.batchBytes+size > cloudWatchMaxBatchBytes {
+		c.send()
+	}
+	c.events = append(c.events, CloudWatchEvent{
+		Timestamp: when.UnixNano() / int64(time.Millisecond),
+		Message:   msg,
+	})
+	c.batchBytes += size
+
+	err := c.err
+	c.err = nil
+	return err
+}
+
+// send 发送当前批次，token 失效时用服务端期望的 token 重发一次；调用时需持有锁
+func (c *cloudWatchWriter) send() {
+	if len(c.events) == 0 {
+		return
+	}
+	next, err := c.client.PutLogEvents(c.Group, c.Stream, c.events, c.token)
+	if tokenErr, ok := err.(*CloudWatchSequenceTokenError); ok {
+		next, err = c.client.PutLogEvents(c.Group, c.Stream, c.events, tokenErr.ExpectedToken)
+	}
+	if err != nil {
+		c.err = fmt.Errorf("logs: cloudwatch PutLogEvents dropped %d events: %v", len(c.events), err)
+	} else {
+		c.token = next
+	}
+	c.events = nil
+	c.batchBytes = 0
+}
+
+// takeErr 返回并清除发送失败的错误，用于报告 Flush 和 Destroy 里发送的最后一批
+func (c *cloudWatchWriter) takeErr() error {
+	c.Lock()
+	defer c.Unlock()
+	err := c.err
+	c.err = nil
+	return err
+}
+
+// GetLevel return the level of this writer.
+func (c *cloudWatchWriter) GetLevel() int {
+	return c.Level.get()
+}
+
+// SetLevel set the level of this writer.
+func (c *cloudWatchWriter) SetLevel(level int) {
+	c.Level.set(level)
+}
+
+// Destroy send the remaining events.
+func (c *cloudWatchWriter) Destroy() {
+	c.Flush()
+}
+
+// Flush send the current batch.
+func (c *cloudWatchWriter) Flush() {
+	c.Lock()
+	c.send()
+	c.Unlock()
+}
+
+func init() {
+	Register(AdapterCloudWatch, NewCloudWatch)
+}
@@ -0,0 +1,80 @@
+package logs
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewCloudWatchAdapter(t *testing.T) {
+	clients := []*fakeCloudWatch{{}, {}}
+	loggers := make([]*AppLogger, len(clients))
+	for i, c := range clients {
+		lg := NewCloudWatchAdapter(c)
+		if err := lg.Init(`{"group":"app","stream":"web"}`); err != nil {
+			t.Fatal(err)
+		}
+		al := NewAppLogger()
+		al.RemoveLogger(AdapterConsole)
+		if err := al.AddAdapter(AdapterCloudWatch, lg); err != nil {
+			t.Fatal(err)
+		}
+		loggers[i] = al
+	}
+	loggers[0].Info("first")
+	loggers[1].Info("second")
+	loggers[1].Info("third")
+	for _, al := range loggers {
+		al.Close()
+	}
+	if n := len(clients[0].events); n != 1 {
+		t.Errorf("client 0 got %d events", n)
+	}
+	if n := len(clients[1].events); n != 2 {
+		t.Errorf("client 1 got %d events", n)
+	}
+}
+
+func TestCloudWatchAdapterInit(t *testing.T) {
+	if err := NewCloudWatchAdapter(nil).Init(`{"group":"app","stream":"web"}`); err == nil {
+		t.Error("Init succeeded without a client")
+	}
+	if err := NewCloudWatchAdapter(&fakeCloudWatch{}).Init(`{"group":"app"}`); err == nil {
+		t.Error("Init succeeded without a stream")
+	}
+}
+
+// 报告 Flush 和 Close 时发送的最后一批的错误
+func TestCloudWatchFinalBatchError(t *testing.T) {
+	client := &fakeCloudWatch{err: errors.New("throttled")}
+	lg := NewCloudWatchAdapter(client)
+	if err := lg.Init(`{"group":"app","stream":"web"}`); err != nil {
+		t.Fatal(err)
+	}
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	al.AddAdapter(AdapterCloudWatch, lg)
+	al.SetAuditMode(true)
+	var reported []string
+	al.SetErrorHandler(func(name string, err error) {
+		reported = append(reported, name+": "+err.Error())
+	})
+
+	al.Info("flushed")
+	if err := al.FlushAdapter(AdapterCloudWatch); err == nil || !strings.Contains(err.Error(), "throttled") {
+		t.Errorf("FlushAdapter returned %v", err)
+	}
+	if _, name, err := al.LastError(); name != AdapterCloudWatch || err == nil {
+		t.Errorf("LastError() = %q, %v", name, err)
+	}
+	if err := al.LogSync(LevelInfo, "sync"); err == nil {
+		t.Error("LogSync returned no error")
+	}
+	al.Info("closed")
+	if err := al.CloseErr(); err == nil || !strings.Contains(err.Error(), "dropped 1 events: throttled") {
+		t.Errorf("CloseErr() = %v", err)
+	}
+	if len(reported) != 3 {
+		t.Errorf("reported %q", reported)
+	}
+}
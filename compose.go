@@ -0,0 +1,53 @@
+package logs
+
+import (
+	"fmt"
+	"time"
+)
+
+// appLoggerWriter 把 Record 转交给另一个 AppLogger 的 Logger，由 Compose 使用
+type appLoggerWriter struct {
+	al *AppLogger
+}
+
+// Compose 返回一个把每条log转交给 loggers 的 AppLogger，如同时写到应用自己的和共享的审计 AppLogger；
+// 每个 AppLogger 按自己的级别过滤，用自己的 Logger 和同步/异步方式写出，
+// 调用位置、前缀和字段以返回的 AppLogger 上的配置为准
+func Compose(loggers ...*AppLogger) *AppLogger {
+	composite := newAppLoggerNoConsole()
+	for i, l := range loggers {
+		composite.outputs = append(composite.outputs, &nameLogger{
+			name:   fmt.Sprintf("compose%d", i),
+			Logger: &appLoggerWriter{al: l},
+		})
+	}
+	return composite
+}
+
+// Init implementing method. empty.
+func (w *appLoggerWriter) Init(jsonConfig string) error {
+	return nil
+}
+
+// WriteMsg write message as a record of the target AppLogger.
+func (w *appLoggerWriter) WriteMsg(when time.Time, msg string, level int) error {
+	return w.WriteRecord(&Record{When: when, Level: level, Msg: msg, text: msg})
+}
+
+// WriteRecord hand r to the target AppLogger if its level allows.
+func (w *appLoggerWriter) WriteRecord(r *Record) error {
+	if !r.noLevel && !w.al.Enabled(r.Level) {
+		return nil
+	}
+	return w.al.dispatch(*r, nil)
+}
+
+// Destroy implementing method. empty, the target AppLogger is closed by its owner.
+func (w *appLoggerWriter) Destroy() {
+
+}
+
+// Flush flush the target AppLogger.
+func (w *appLoggerWriter) Flush() {
+	w.al.Flush()
+}
@@ -0,0 +1,52 @@
+package logs
+
+import (
+	"testing"
+)
+
+func TestCompose(t *testing.T) {
+	app, appOut := newTestLogger(t)
+	defer app.Close()
+	audit, auditOut := newTestLogger(t)
+	defer audit.Close()
+	audit.SetLevel(LevelWarning)
+
+	al := Compose(app, audit)
+	defer al.Close()
+	al.Info("user %s logged in", "u1")
+	al.Warn("password changed")
+	al.Flush()
+
+	if lines := appOut.lines(); len(lines) != 2 || lines[0] != "[I]  user u1 logged in" || lines[1] != "[W]  password changed" {
+		t.Errorf("app got %q", lines)
+	}
+	if lines := auditOut.lines(); len(lines) != 1 || lines[0] != "[W]  password changed" {
+		t.Errorf("audit got %q", lines)
+	}
+	if appOut.flushed == 0 || auditOut.flushed == 0 {
+		t.Error("Flush not forwarded")
+	}
+}
+
+// Compose 不创建用不到的缺省 console
+func TestComposeNoConsole(t *testing.T) {
+	newConsole := adapters[AdapterConsole]
+	created := 0
+	adapters[AdapterConsole] = func() Logger {
+		created++
+		return newConsole()
+	}
+	defer func() { adapters[AdapterConsole] = newConsole }()
+
+	app, _ := newTestLogger(t)
+	defer app.Close()
+	created = 0
+	al := Compose(app)
+	defer al.Close()
+	if created != 0 {
+		t.Errorf("Compose created %d console adapters", created)
+	}
+	if len(al.outputs) != 1 {
+		t.Errorf("%d outputs", len(al.outputs))
+	}
+}
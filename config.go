@@ -0,0 +1,75 @@
+package logs
+
+import (
+	"encoding/json"
+	"sync/atomic"
+)
+
+// appConfig ConfigJSON 和 LoadConfig 使用的格式
+type appConfig struct {
+	Level        int             `json:"level"`
+	Levels       []int           `json:"levels,omitempty"` // 打开的级别，有时代替 Level，可以表示 EnableLevel 单独开关的级别
+	Async        bool            `json:"async"`
+	AsyncLen     int64           `json:"async_len,omitempty"`
+	AsyncWorkers int             `json:"async_workers,omitempty"`
+	Adapters     []adapterConfig `json:"adapters"`
+}
+
+type adapterConfig struct {
+	Name   string          `json:"name"`
+	Config json.RawMessage `json:"config"`
+}
+
+// ConfigJSON 返回当前配置的 JSON，包括级别、异步设置和每个 Logger 带 json tag 的配置，可以交给 LoadConfig 重新加载；
+// 级别同时写成 level 和 levels，EnableLevel 单独打开或关闭的级别也能还原；
+// 用 AddWriter 添加的 Logger 也会列出，但不能被 LoadConfig 加载
+func (al *AppLogger) ConfigJSON() string {
+	al.lock.Lock()
+	c := appConfig{
+		Level:    al.getLevel(),
+		Async:    al.asynchronous,
+		Adapters: []adapterConfig{},
+	}
+	for level := LevelError; level <= LevelDebug; level++ {
+		if al.Enabled(level) {
+			c.Levels = append(c.Levels, level)
+		}
+	}
+	if c.Async {
+		c.AsyncLen = al.msgChanLen
+		c.AsyncWorkers = al.workers
+	}
+	al.lock.Unlock()
+	for _, l := range al.outputs {
+		config, err := json.Marshal(l.Logger)
+		if err != nil {
+			config, _ = json.Marshal(map[string]string{"error": err.Error()})
+		}
+		c.Adapters = append(c.Adapters, adapterConfig{Name: l.name, Config: config})
+	}
+	b, _ := json.Marshal(c)
+	return string(b)
+}
+
+// LoadConfig 按 ConfigJSON 的格式设置级别、开启异步并添加 Logger，已经有同名的 Logger 时用新配置替换旧的。
+// 和 WatchConfigFile 一样，新的 Logger 都初始化成功之后才在 Drain 和 Resume 之间一次替换，有一个失败时保持原来的配置
+func (al *AppLogger) LoadConfig(jsonConfig string) error {
+	al.ensureInit()
+	_, err := al.applyConfigFile([]byte(jsonConfig), nil)
+	return err
+}
+
+// setLevels 按配置设置级别，有 levels 时只打开其中的级别
+func (al *AppLogger) setLevels(c *appConfig) {
+	if c.Levels == nil {
+		al.SetLevel(c.Level)
+		return
+	}
+	var mask int32
+	for _, level := range c.Levels {
+		if level >= LevelError && level <= LevelDebug {
+			mask |= 1 << uint(level)
+		}
+	}
+	atomic.StoreInt32(&al.levelMask, mask)
+}
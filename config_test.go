@@ -0,0 +1,129 @@
+package logs
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestConfigJSONRoundTrip(t *testing.T) {
+	inTempDir(t)
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	if err := al.AddLogger(AdapterConsole, `{"level":1,"color":false}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := al.AddLogger(AdapterFile, `{"filename":"app.log","append":false,"header":"#v1"}`); err != nil {
+		t.Fatal(err)
+	}
+	al.SetLevel(LevelInfo)
+	al.AsyncWorkers(2).Async(100)
+	snapshot := al.ConfigJSON()
+	al.Close()
+
+	var c appConfig
+	if err := json.Unmarshal([]byte(snapshot), &c); err != nil {
+		t.Fatalf("%v in %s", err, snapshot)
+	}
+	if c.Level != LevelInfo || !c.Async || c.AsyncLen != 100 || c.AsyncWorkers != 2 || len(c.Adapters) != 2 {
+		t.Errorf("snapshot %s", snapshot)
+	}
+	if !strings.Contains(string(c.Adapters[1].Config), `"filename":"app.log"`) {
+		t.Errorf("file config %s", c.Adapters[1].Config)
+	}
+
+	loaded := NewAppLogger()
+	loaded.RemoveLogger(AdapterConsole)
+	if err := loaded.LoadConfig(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	defer loaded.Close()
+	if got := loaded.ConfigJSON(); got != snapshot {
+		t.Errorf("reloaded config\n%s\nwant\n%s", got, snapshot)
+	}
+}
+
+func TestLoadConfigUnknownAdapter(t *testing.T) {
+	al, _ := newTestLogger(t)
+	defer al.Close()
+	al.SetLevel(LevelWarning)
+	err := al.LoadConfig(`{"level":3,"adapters":[{"name":"nosuch","config":{}}]}`)
+	if err == nil {
+		t.Fatal("loaded an unknown adapter")
+	}
+	if al.Enabled(LevelDebug) {
+		t.Error("level changed by a rejected config")
+	}
+}
+
+// EnableLevel 单独关闭的级别经过 ConfigJSON 和 LoadConfig 之后仍然关闭
+func TestConfigJSONLevelMask(t *testing.T) {
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	al.SetLevel(LevelInfo)
+	al.EnableLevel(LevelWarning, false)
+	snapshot := al.ConfigJSON()
+	al.Close()
+
+	loaded := NewAppLogger()
+	loaded.RemoveLogger(AdapterConsole)
+	defer loaded.Close()
+	if err := loaded.LoadConfig(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	for level, want := range []bool{true, false, true, false} {
+		if got := loaded.Enabled(level); got != want {
+			t.Errorf("level %d enabled %v, want %v in %s", level, got, want, snapshot)
+		}
+	}
+}
+
+// 同名的 Logger 换成新配置之前，异步队列里的log都写到旧的 Logger
+func TestLoadConfigReplaceUnderDrain(t *testing.T) {
+	inTempDir(t)
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	defer al.Close()
+	if err := al.AddLogger(AdapterFile, `{"filename":"old.log"}`); err != nil {
+		t.Fatal(err)
+	}
+	al.Async(1000)
+	for i := 0; i < 100; i++ {
+		al.Info("before %d", i)
+	}
+	if err := al.LoadConfig(`{"level":3,"adapters":[{"name":"file","config":{"filename":"new.log"}}]}`); err != nil {
+		t.Fatal(err)
+	}
+	al.Info("after")
+	al.Flush()
+
+	if got := strings.Count(readFile(t, "old.log"), "before"); got != 100 {
+		t.Errorf("old.log has %d of 100 records", got)
+	}
+	if got := readFile(t, "new.log"); strings.Contains(got, "before") || !strings.Contains(got, "after") {
+		t.Errorf("new.log got %q", got)
+	}
+	if n := len(al.outputs); n != 1 {
+		t.Errorf("%d outputs after replacing", n)
+	}
+}
+
+// 有一个 Logger 初始化失败时不改动原来的配置
+func TestLoadConfigInitFailure(t *testing.T) {
+	inTempDir(t)
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.SetLevel(LevelWarning)
+	err := al.LoadConfig(`{"level":3,"adapters":[` +
+		`{"name":"console","config":{}},` +
+		`{"name":"file","config":{"filename":"missing/dir/app.log"}}]}`)
+	if err == nil {
+		t.Fatal("loaded a file adapter that cannot open its file")
+	}
+	if n := len(al.outputs); n != 1 || al.outputs[0].Logger != c {
+		t.Errorf("outputs changed by a rejected config: %d", n)
+	}
+	if al.Enabled(LevelDebug) {
+		t.Error("level changed by a rejected config")
+	}
+}
@@ -0,0 +1,211 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// 缺省检查配置文件是否修改的间隔
+const defaultConfigWatchInterval = time.Second
+
+// configWatch WatchConfigFile 的状态
+type configWatch struct {
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// WatchConfigFile 按 LoadConfig 的格式加载 path 中的配置，之后定时检查文件，内容变化时重新应用：
+// 修改级别，添加文件中新增的 Logger，配置变化的 Logger 用新配置重新创建，文件中删掉的 Logger 被刷新并销毁。
+// 只有之前从这个文件加载的 Logger 才会因为被删掉而移除，AddLogger、AddWriter 添加的不受影响。
+// 新的 Logger 都初始化成功之后才在 Drain 和 Resume 之间一次替换，失败时保持原来的配置并通过 error handler 报告。
+// 再次调用时停止之前的检查，Close 时停止检查
+func (al *AppLogger) WatchConfigFile(path string) error {
+	al.ensureInit()
+	al.stopConfigWatch()
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	loaded, err := al.applyConfigFile(content, nil)
+	if err != nil {
+		return err
+	}
+
+	al.lock.Lock()
+	defer al.lock.Unlock()
+	w := &al.configWatch
+	if w.interval <= 0 {
+		w.interval = defaultConfigWatchInterval
+	}
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+	go al.watchConfig(path, content, loaded, w.interval, w.stop, w.done)
+	return nil
+}
+
+// SetConfigWatchInterval 设置 WatchConfigFile 检查文件的间隔，缺省为1秒，在 WatchConfigFile 之前调用
+func (al *AppLogger) SetConfigWatchInterval(d time.Duration) {
+	al.lock.Lock()
+	al.configWatch.interval = d
+	al.lock.Unlock()
+}
+
+// stopConfigWatch 停止 WatchConfigFile 的检查，等正在进行的重新加载完成
+func (al *AppLogger) stopConfigWatch() {
+	al.lock.Lock()
+	w := &al.configWatch
+	stop, done := w.stop, w.done
+	w.stop, w.done = nil, nil
+	al.lock.Unlock()
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// watchConfig 每隔 interval 读一次 path，内容和 content 不同时重新应用
+func (al *AppLogger) watchConfig(path string, content []byte, loaded map[string]string, interval time.Duration, stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+		next, err := ioutil.ReadFile(path)
+		if err != nil {
+			// 编辑器保存时文件可能暂时不存在，等下一次
+			if !os.IsNotExist(err) {
+				al.reportError("config", err)
+			}
+			continue
+		}
+		if bytes.Equal(next, content) {
+			continue
+		}
+		// 出错时也记下内容，同一份错误的配置不重复报告
+		content = next
+		if l, err := al.applyConfigFile(next, loaded); err != nil {
+			al.reportError("config", err)
+		} else {
+			loaded = l
+		}
+	}
+}
+
+// applyConfigFile 应用配置文件的内容，loaded 为上次从文件加载的 Logger 名字和配置，返回这次加载的
+func (al *AppLogger) applyConfigFile(content []byte, loaded map[string]string) (map[string]string, error) {
+	var c appConfig
+	if err := json.Unmarshal(content, &c); err != nil {
+		return nil, err
+	}
+	next := make(map[string]string, len(c.Adapters))
+	for _, a := range c.Adapters {
+		if _, ok := adapters[adapterType(a.Name)]; !ok {
+			return nil, fmt.Errorf("logs: unknown adaptername %q (forgotten Register?)", a.Name)
+		}
+		if _, dup := next[a.Name]; dup {
+			return nil, fmt.Errorf("logs: duplicate adaptername %q in config", a.Name)
+		}
+		config := string(a.Config)
+		if config == "" {
+			config = "{}"
+		}
+		next[a.Name] = config
+	}
+
+	count := len(al.outputs)
+	for name := range loaded {
+		if _, ok := next[name]; !ok && al.hasOutput(name) {
+			count--
+		}
+	}
+	for name := range next {
+		if !al.hasOutput(name) {
+			count++
+		}
+	}
+	al.lock.Lock()
+	max := al.maxAdapters
+	al.lock.Unlock()
+	if max > 0 && count > max {
+		return nil, fmt.Errorf("logs: too many adapters (max %d)", max)
+	}
+
+	// 先创建并初始化所有需要新建的 Logger，有一个失败就全部放弃
+	created := make(map[string]Logger)
+	for _, a := range c.Adapters {
+		if config, ok := loaded[a.Name]; ok && config == next[a.Name] && al.hasOutput(a.Name) {
+			continue
+		}
+		lg := adapters[adapterType(a.Name)]()
+		if err := lg.Init(next[a.Name]); err != nil {
+			for _, lg := range created {
+				lg.Destroy()
+			}
+			return nil, fmt.Errorf("logs: init %q: %v", a.Name, err)
+		}
+		al.applyTheme(lg)
+		created[a.Name] = lg
+	}
+
+	if al.drain() {
+		defer al.Resume()
+	}
+	al.setLevels(&c)
+	outputs := make([]*nameLogger, 0, count)
+	var retired []*nameLogger
+	for _, l := range al.outputs {
+		_, fromFile := loaded[l.name]
+		_, inFile := next[l.name]
+		if lg, ok := created[l.name]; ok {
+			// 配置变化，在原来的位置换成新的
+			retired = append(retired, l)
+			outputs = append(outputs, &nameLogger{name: l.name, Logger: lg})
+			delete(created, l.name)
+			continue
+		}
+		if fromFile && !inFile {
+			retired = append(retired, l)
+			continue
+		}
+		outputs = append(outputs, l)
+	}
+	for _, a := range c.Adapters {
+		if lg, ok := created[a.Name]; ok {
+			outputs = append(outputs, &nameLogger{name: a.Name, Logger: lg})
+			al.replayBootstrap(lg)
+		}
+	}
+	al.outputs = outputs
+	for _, l := range retired {
+		l.Flush()
+		l.Destroy()
+		al.reportBatchErr(l)
+	}
+
+	if c.Async {
+		if c.AsyncWorkers > 0 {
+			al.AsyncWorkers(c.AsyncWorkers)
+		}
+		al.Async(c.AsyncLen)
+	}
+	return next, nil
+}
+
+// hasOutput 返回是否已经有名为 name 的 Logger
+func (al *AppLogger) hasOutput(name string) bool {
+	for _, l := range al.outputs {
+		if l.name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package logs
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitFor 每隔几毫秒检查一次 cond，d 之内没有成立就结束测试
+func waitFor(t *testing.T, d time.Duration, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(d)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s not seen within %v", what, d)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func writeConfig(t *testing.T, content string) {
+	t.Helper()
+	if err := ioutil.WriteFile("logs.json", []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatchConfigFile(t *testing.T) {
+	inTempDir(t)
+	al, c := newTestLogger(t)
+	var configErrs int32
+	al.SetErrorHandler(func(name string, err error) {
+		if name == "config" {
+			atomic.AddInt32(&configErrs, 1)
+		}
+	})
+	al.SetConfigWatchInterval(10 * time.Millisecond)
+	writeConfig(t, `{"level":1,"adapters":[{"name":"file#a","config":{"filename":"a.log","color":false}}]}`)
+	if err := al.WatchConfigFile("logs.json"); err != nil {
+		t.Fatal(err)
+	}
+	al.Info("hidden")
+	al.Warn("to a")
+
+	writeConfig(t, `{"level":3,"adapters":[{"name":"file#b","config":{"filename":"b.log","color":false}}]}`)
+	waitFor(t, 2*time.Second, "new config", func() bool {
+		al.Debug("probe")
+		b, _ := ioutil.ReadFile("b.log")
+		return strings.Contains(string(b), "probe")
+	})
+	al.Info("to b")
+
+	// 错误的配置被报告，原来的配置不变
+	writeConfig(t, `{"level":0,"adapters":[{"name":"nosuch","config":{}}]}`)
+	waitFor(t, 2*time.Second, "config error", func() bool { return atomic.LoadInt32(&configErrs) > 0 })
+	al.Debug("still debug")
+	al.Close()
+
+	if got := readFile(t, "a.log"); !strings.HasSuffix(got, "[W]  to a\n") || strings.Count(got, "\n") != 1 {
+		t.Errorf("a.log got %q", got)
+	}
+	if got := readFile(t, "b.log"); !strings.Contains(got, "[I]  to b\n") || !strings.HasSuffix(got, "[D]  still debug\n") {
+		t.Errorf("b.log got %q", got)
+	}
+	// 不是从配置文件加载的 Logger 一直保留
+	if lines := c.lines(); !contains(lines, "[W]  to a") || !contains(lines, "[D]  still debug") || contains(lines, "[I]  hidden") {
+		t.Errorf("capture got %q", lines)
+	}
+}
+
+func TestWatchConfigFileErrors(t *testing.T) {
+	inTempDir(t)
+	al, _ := newTestLogger(t)
+	defer al.Close()
+	if err := al.WatchConfigFile("missing.json"); !os.IsNotExist(err) {
+		t.Errorf("missing file: %v", err)
+	}
+	writeConfig(t, `{"adapters":[{"name":"file#x","config":{}},{"name":"file#x","config":{}}]}`)
+	if err := al.WatchConfigFile("logs.json"); err == nil {
+		t.Error("accepted a duplicate adapter name")
+	}
+}
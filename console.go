@@ -1,9 +1,12 @@
-package logs 
+package logs
 
 import (
+	"bufio"
 	"encoding/json"
+	"fmt"
 	"os"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -19,18 +22,170 @@ func newBrush(color string) brush {
 	}
 }
 
-var colors = []brush{
-	newBrush("1;31"), // Error              高亮度 red
-	newBrush("1;33"), // Warning            yellow
-	newBrush("1;32"), // Informational      green
-	newBrush("1;37"), // Debug              green
+// palette 一套各级别的颜色
+type palette struct {
+	brushes [LevelDebug + 1]brush
+	// tokens 预先上色的各种写法的级别，避免每行都重新拼接
+	tokens [LevelStyleNumeric + 1][LevelDebug + 1]string
+}
+
+// newPalette 按 Error、Warning、Info、Debug 的顺序给出各级别的颜色代码
+func newPalette(codes [LevelDebug + 1]string) *palette {
+	p := &palette{}
+	for level, code := range codes {
+		p.brushes[level] = newBrush(code)
+	}
+	for style, tokens := range levelTokens {
+		for level, token := range tokens {
+			p.tokens[style][level] = p.brushes[level](token)
+		}
+	}
+	return p
+}
+
+// colorThemes SetColorTheme 可以选择的配色
+var colorThemes = map[string]*palette{
+	"classic": newPalette([LevelDebug + 1]string{
+		"1;31", // Error              高亮度 red
+		"1;33", // Warning            yellow
+		"1;32", // Informational      green
+		"1;37", // Debug              white
+	}),
+	"solarized": newPalette([LevelDebug + 1]string{
+		"38;5;160", // red
+		"38;5;136", // yellow
+		"38;5;64",  // green
+		"38;5;245", // base1
+	}),
+	"monochrome": newPalette([LevelDebug + 1]string{
+		"1;7", // 反白加粗
+		"1",   // 加粗
+		"0",
+		"2", // 暗淡
+	}),
+}
+
+var defaultPalette = colorThemes["classic"]
+
+// brush 返回 level 的颜色，未知的级别不上色
+func (p *palette) brush(level int) brush {
+	if !validLevel(level) {
+		return noBrush
+	}
+	return p.brushes[level]
+}
+
+// noBrush 原样返回文字
+func noBrush(text string) string {
+	return text
+}
+
+// colorLevelPrefix 只给行首的级别上色，级别可以是任意一种 LevelStyle 的写法，消息内容里出现的同样字符串不受影响；
+// 未知的级别原样返回
+func (p *palette) colorLevelPrefix(msg string, level int) string {
+	if !validLevel(level) {
+		return msg
+	}
+	for style, tokens := range levelTokens {
+		if strings.HasPrefix(msg, tokens[level]+" ") {
+			return p.tokens[style][level] + msg[len(tokens[level]):]
+		}
+	}
+	return msg
+}
+
+// colorTheme 嵌入到支持配色的 Logger 中，保存 AppLogger.SetColorTheme 设置的配色
+type colorTheme struct {
+	v atomic.Value
+}
+
+func (t *colorTheme) palette() *palette {
+	if p, ok := t.v.Load().(*palette); ok {
+		return p
+	}
+	return defaultPalette
+}
+
+func (t *colorTheme) setPalette(p *palette) {
+	t.v.Store(p)
+}
+
+// themedLogger 支持配色的 Logger
+type themedLogger interface {
+	setPalette(p *palette)
+}
+
+// SetColorTheme 设置这个 AppLogger 的所有 Logger 使用的配色："classic"（缺省）、"solarized" 或 "monochrome"，
+// 之后添加的 Logger 也使用这个配色
+func (al *AppLogger) SetColorTheme(name string) error {
+	p, ok := colorThemes[name]
+	if !ok {
+		return fmt.Errorf("logs: unknown color theme %q", name)
+	}
+	al.lock.Lock()
+	al.theme = p
+	al.lock.Unlock()
+	for _, l := range al.outputs {
+		al.applyTheme(l.Logger)
+	}
+	return nil
+}
+
+// applyTheme 把 SetColorTheme 设置的配色用到 lg 上
+func (al *AppLogger) applyTheme(lg Logger) {
+	al.lock.Lock()
+	p := al.theme
+	al.lock.Unlock()
+	if t, ok := lg.(themedLogger); ok && p != nil {
+		t.setPalette(p)
+	}
 }
 
 // consoleWriter implements LoggerInterface and writes messages to terminal.
 type consoleWriter struct {
-	lg       *logWriter
-	Level    int  `json:"level"`
-	Colorful bool `json:"color"` //this filed is useful only when system's terminal supports color
+	colorTheme
+	lg         *logWriter
+	Level      adapterLevel `json:"level"`
+	Colorful   bool         `json:"color"` //this filed is useful only when system's terminal supports color
+	NoNewline  bool         `json:"no_newline"`
+	TimeFormat string       `json:"time_format"` // 时间头的格式，如 "2006-01-02T15:04:05Z07:00"，为空时使用默认格式
+	// TimeZone 时间头使用的时区，如 "UTC"；SecondTimeZone 不为空时在时间后面的括号里再写出这个时区的时间，如 "(02:00 PST)"
+	TimeZone       string `json:"time_zone"`
+	SecondTimeZone string `json:"second_time_zone"`
+	JSON           bool   `json:"json"`        // 每行输出一个 JSON 对象
+	EscapeHTML     bool   `json:"escape_html"` // JSON 输出时是否把 <>& 转义为 \u003c 这种形式
+	// ColorFullLine 为 true 时用级别的颜色包住整行，而不只是级别，只对终端有效
+	ColorFullLine bool `json:"color_full_line"`
+	Buffered      bool `json:"buffered"` // 先写到缓冲区，每 FlushMs 毫秒和 Flush 时再写到终端
+	FlushMs       int  `json:"flush_ms"`
+	// WrapColumn 大于 0 时每行超过这么多个字符就折行，后续行缩进 WrapIndent 个空格
+	WrapColumn int `json:"wrap_column"`
+	WrapIndent int `json:"wrap_indent"`
+	// LevelAttrs 按级别名字给行首的级别加上粗体、下划线等属性，如 {"error":"bold","warn":"underline"}，
+	// 不依赖颜色，color 为 false 时也有效；可用的属性有 bold、dim、italic、underline、reverse
+	LevelAttrs map[string]string `json:"level_attrs"`
+
+	attrs [LevelDebug + 1]brush
+	buf   *lineBuffer
+	stop  chan struct{}
+}
+
+// buffered 模式下 flush_ms 的默认值
+const defaultConsoleFlushMs = 100
+
+// lineBuffer 按整行缓冲的 bufio.Writer，剩余空间放不下一整行时先把已有的行写出，
+// 保证一行（包括其中的颜色转义）不会被拆到两次写出里
+type lineBuffer struct {
+	*bufio.Writer
+}
+
+func (b *lineBuffer) Write(p []byte) (int, error) {
+	if len(p) > b.Available() && b.Buffered() > 0 {
+		if err := b.Writer.Flush(); err != nil {
+			return 0, err
+		}
+	}
+	return b.Writer.Write(p)
 }
 
 // NewConsole create ConsoleWriter returning as LoggerInterface.
@@ -49,31 +204,179 @@ func (c *consoleWriter) Init(jsonConfig string) error {
 	if len(jsonConfig) == 0 {
 		return nil
 	}
-	return json.Unmarshal([]byte(jsonConfig), c)
+	if err := json.Unmarshal([]byte(jsonConfig), c); err != nil {
+		return err
+	}
+	zones, err := loadTimeZones(c.TimeZone, c.SecondTimeZone)
+	if err != nil {
+		return err
+	}
+	c.lg.zones = zones
+	if c.attrs, err = parseLevelAttrs(c.LevelAttrs); err != nil {
+		return err
+	}
+	c.lg.noNewline = c.NoNewline
+	c.lg.timeFormat = c.TimeFormat
+	if c.WrapColumn > 0 && c.WrapIndent < c.WrapColumn {
+		c.lg.wrapColumn = c.WrapColumn
+		c.lg.wrapIndent = strings.Repeat(" ", c.WrapIndent)
+	}
+	if c.Buffered && c.buf == nil {
+		if c.FlushMs <= 0 {
+			c.FlushMs = defaultConsoleFlushMs
+		}
+		c.buf = &lineBuffer{bufio.NewWriter(c.lg.writer)}
+		c.lg.writer = c.buf
+		c.stop = make(chan struct{})
+		go c.flushLoop(time.Duration(c.FlushMs)*time.Millisecond, c.stop)
+	}
+	return nil
+}
+
+// flushLoop 每隔 interval 把缓冲区写到终端，直到 Destroy 关闭 stop
+func (c *consoleWriter) flushLoop(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.Flush()
+		case <-stop:
+			return
+		}
+	}
 }
 
 // WriteMsg write message in console.
 func (c *consoleWriter) WriteMsg(when time.Time, msg string, level int) error {
-	if level > c.Level {
+	return c.writeText(when, 0, msg, level)
+}
+
+// writeText 写一行文本，precision 见 AppLogger.SetTimePrecision
+func (c *consoleWriter) writeText(when time.Time, precision time.Duration, msg string, level int) error {
+	if level > c.Level.get() {
 		return nil
 	}
-	if c.Colorful {
-		msg = strings.Replace(msg, levelPrefix[level], colors[level](levelPrefix[level]), 1)
+	if c.ColorFullLine && c.Colorful {
+		b := c.palette().brush(level)
+		if validLevel(level) && c.attrs[level] != nil {
+			msg = c.emphasizeFullLine(msg, level, b)
+		}
+		_, err := c.lg.writeLine(when, precision, msg, b)
+		return err
 	}
-	c.lg.writeln(when, msg)
-	return nil
+	if validLevel(level) && c.attrs[level] != nil {
+		msg = c.emphasizeLevelPrefix(msg, level)
+	} else if c.Colorful {
+		msg = c.palette().colorLevelPrefix(msg, level)
+	}
+	_, err := c.lg.writeLine(when, precision, msg, nil)
+	return err
 }
 
-// Destroy implementing method. empty.
-func (c *consoleWriter) Destroy() {
+// emphasizeLevelPrefix 给行首的级别加上 LevelAttrs 配置的属性，开启了颜色时同时上色
+func (c *consoleWriter) emphasizeLevelPrefix(msg string, level int) string {
+	for style, tokens := range levelTokens {
+		if strings.HasPrefix(msg, tokens[level]+" ") {
+			token := tokens[level]
+			if c.Colorful {
+				token = c.palette().tokens[style][level]
+			}
+			return c.attrs[level](token) + msg[len(tokens[level]):]
+		}
+	}
+	return msg
+}
 
+// emphasizeFullLine 整行上色时给行首的级别加上 LevelAttrs 配置的属性，属性结尾的复位会去掉整行的颜色，级别之后的部分用 b 重新上色
+func (c *consoleWriter) emphasizeFullLine(msg string, level int, b brush) string {
+	for _, tokens := range levelTokens {
+		if token := tokens[level]; strings.HasPrefix(msg, token+" ") {
+			return c.attrs[level](token) + b(msg[len(token):])
+		}
+	}
+	return msg
 }
 
-// Flush implementing method. empty.
-func (c *consoleWriter) Flush() {
+// sgrAttrs LevelAttrs 可以使用的属性
+var sgrAttrs = map[string]string{
+	"bold":      "1",
+	"dim":       "2",
+	"italic":    "3",
+	"underline": "4",
+	"reverse":   "7",
+}
 
+// parseLevelAttrs 把 {"error":"bold,underline"} 这样的配置转成各级别的 brush
+func parseLevelAttrs(config map[string]string) (attrs [LevelDebug + 1]brush, err error) {
+	for name, value := range config {
+		level, ok := levelNames[name]
+		if !ok {
+			return attrs, fmt.Errorf("logs: unknown level %q in level_attrs", name)
+		}
+		var codes []string
+		for _, attr := range strings.Split(value, ",") {
+			code, ok := sgrAttrs[strings.TrimSpace(attr)]
+			if !ok {
+				return attrs, fmt.Errorf("logs: unknown attribute %q in level_attrs", attr)
+			}
+			codes = append(codes, code)
+		}
+		attrs[level] = newBrush(strings.Join(codes, ";"))
+	}
+	return attrs, nil
+}
+
+// WriteRecord write record as a JSON line when json is enabled, otherwise same as WriteMsg.
+func (c *consoleWriter) WriteRecord(r *Record) error {
+	if !c.JSON {
+		return c.writeText(r.When, r.precision, r.String(), r.Level)
+	}
+	if r.Level > c.Level.get() {
+		return nil
+	}
+	_, err := c.lg.writeJSON(r, c.EscapeHTML)
+	return err
+}
+
+// WriteRaw write the bytes produced by the AppLogger's Formatter.
+func (c *consoleWriter) WriteRaw(p []byte, level int) error {
+	if level > c.Level.get() {
+		return nil
+	}
+	_, err := c.lg.writeRaw(p)
+	return err
+}
+
+// GetLevel return the level of this writer.
+func (c *consoleWriter) GetLevel() int {
+	return c.Level.get()
+}
+
+// SetLevel set the level of this writer.
+func (c *consoleWriter) SetLevel(level int) {
+	c.Level.set(level)
+}
+
+// Destroy stop the flush loop and write out the buffer in buffered mode.
+func (c *consoleWriter) Destroy() {
+	if c.stop != nil {
+		close(c.stop)
+		c.stop = nil
+	}
+	c.Flush()
+}
+
+// Flush write out the buffer in buffered mode.
+func (c *consoleWriter) Flush() {
+	if c.buf == nil {
+		return
+	}
+	c.lg.Lock()
+	c.buf.Flush()
+	c.lg.Unlock()
 }
 
 func init() {
 	Register(AdapterConsole, NewConsole)
-}
\ No newline at end of file
+}
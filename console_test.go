@@ -0,0 +1,277 @@
+package logs
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestConsole 返回写到 w 的 consoleWriter，按 jsonConfig 初始化
+func newTestConsole(t testing.TB, w io.Writer, jsonConfig string) *consoleWriter {
+	t.Helper()
+	c := NewConsole().(*consoleWriter)
+	c.lg = newLogWriter(w)
+	if err := c.Init(jsonConfig); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestColorLevelPrefix(t *testing.T) {
+	var buf bytes.Buffer
+	c := newTestConsole(t, &buf, `{"color":true}`)
+	c.WriteMsg(time.Now(), "[I]  body mentions [I] again", LevelInfo)
+	c.WriteMsg(time.Now(), "no level tag", LevelInfo)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %q", buf.String())
+	}
+	want := "\033[1;32m[I]\033[0m  body mentions [I] again"
+	if !strings.HasSuffix(lines[0], want) {
+		t.Errorf("got %q, want suffix %q", lines[0], want)
+	}
+	if strings.Contains(lines[1], "\033[") {
+		t.Errorf("colored a line without a level tag: %q", lines[1])
+	}
+}
+
+func BenchmarkConsoleColorful(b *testing.B) {
+	c := NewConsole().(*consoleWriter)
+	c.lg = newLogWriter(ioutil.Discard)
+	when := time.Now()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.WriteMsg(when, "[I]  [main.go:12] request handled in 3ms", LevelInfo)
+	}
+}
+
+func BenchmarkColorLevelPrefix(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		defaultPalette.colorLevelPrefix("[W]  disk almost full", LevelWarning)
+	}
+}
+
+func TestConsoleNoNewline(t *testing.T) {
+	var buf bytes.Buffer
+	c := newTestConsole(t, &buf, `{"color":false,"no_newline":true}`)
+	c.WriteMsg(time.Now(), "[I]  one", LevelInfo)
+	c.WriteMsg(time.Now(), "[I]  two", LevelInfo)
+	if out := buf.String(); strings.Contains(out, "\n") || !strings.HasSuffix(out, "[I]  two") {
+		t.Errorf("got %q", out)
+	}
+
+	buf.Reset()
+	c = newTestConsole(t, &buf, `{"color":false}`)
+	c.WriteMsg(time.Now(), "[I]  one", LevelInfo)
+	if out := buf.String(); !strings.HasSuffix(out, "[I]  one\n") {
+		t.Errorf("got %q", out)
+	}
+}
+
+// syncBuffer 可以并发读写的 bytes.Buffer
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestConsoleBuffered(t *testing.T) {
+	var out syncBuffer
+	c := newTestConsole(t, &out, `{"color":false,"buffered":true,"flush_ms":20}`)
+	defer c.Destroy()
+	c.WriteMsg(time.Now(), "[I]  buffered", LevelInfo)
+	if got := out.String(); got != "" {
+		t.Fatalf("written before flush: %q", got)
+	}
+	// 定时写出
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(out.String(), "buffered") {
+		if time.Now().After(deadline) {
+			t.Fatal("not flushed by the flush loop")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	c.WriteMsg(time.Now(), "[I]  flushed", LevelInfo)
+	c.Flush()
+	if !strings.Contains(out.String(), "flushed") {
+		t.Errorf("not written by Flush: %q", out.String())
+	}
+	c.WriteMsg(time.Now(), "[I]  destroyed", LevelInfo)
+	c.Destroy()
+	if !strings.Contains(out.String(), "destroyed") {
+		t.Errorf("not written by Destroy: %q", out.String())
+	}
+}
+
+func BenchmarkConsoleBuffered(b *testing.B) {
+	c := newTestConsole(b, ioutil.Discard, `{"color":false,"buffered":true}`)
+	defer c.Destroy()
+	when := time.Now()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.WriteMsg(when, "[I]  request handled", LevelInfo)
+	}
+}
+
+// 关闭颜色时 color_full_line 不起作用
+func TestConsoleColorFullLineNoColor(t *testing.T) {
+	var buf bytes.Buffer
+	c := newTestConsole(t, &buf, `{"color":false,"color_full_line":true,"time_format":"15:04"}`)
+	c.WriteMsg(time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC), "[E]  plain", LevelError)
+	if got := buf.String(); got != "09:30  [E]  plain\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestConsoleColorFullLine(t *testing.T) {
+	var buf bytes.Buffer
+	c := newTestConsole(t, &buf, `{"color":true,"color_full_line":true}`)
+	c.WriteMsg(time.Now(), "[E]  whole line red", LevelError)
+	c.WriteMsg(time.Now(), "[I]  whole line green", LevelInfo)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %q", buf.String())
+	}
+	// 时间头也在颜色里面
+	if !strings.HasPrefix(lines[0], "\033[1;31m") || !strings.HasSuffix(lines[0], "[E]  whole line red\033[0m") {
+		t.Errorf("got %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "\033[1;32m") || !strings.HasSuffix(lines[1], "\033[0m") {
+		t.Errorf("got %q", lines[1])
+	}
+}
+
+func TestSetColorTheme(t *testing.T) {
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	defer al.Close()
+	var before, after bytes.Buffer
+	c1 := newTestConsole(t, &before, `{"color":true}`)
+	al.AddAdapter("before", c1)
+	if err := al.SetColorTheme("solarized"); err != nil {
+		t.Fatal(err)
+	}
+	// 之后添加的 Logger 也使用这个配色
+	c2 := newTestConsole(t, &after, `{"color":true}`)
+	al.AddAdapter("after", c2)
+	al.Error("boom")
+
+	for _, out := range []string{before.String(), after.String()} {
+		if !strings.Contains(out, "\033[38;5;160m[E]\033[0m") {
+			t.Errorf("got %q", out)
+		}
+	}
+	if err := al.SetColorTheme("neon"); err == nil {
+		t.Error("accepted an unknown theme")
+	}
+}
+
+func TestWrapLine(t *testing.T) {
+	cases := []struct {
+		line   string
+		column int
+		indent string
+		want   string
+	}{
+		{"short", 10, "  ", "short"},
+		{"abcdefghij", 4, "  ", "abcd\n  ef\n  gh\n  ij"},
+		{"\033[1;31mabcdef\033[0m", 3, "", "\033[1;31mabc\ndef\033[0m"},
+		{"ab\ncdef", 3, " ", "ab\ncde\n f"},
+		{"日本語テキスト", 3, "", "日本語\nテキス\nト"},
+	}
+	for _, c := range cases {
+		if got := string(wrapLine([]byte(c.line), c.column, c.indent)); got != c.want {
+			t.Errorf("wrapLine(%q, %d, %q) = %q, want %q", c.line, c.column, c.indent, got, c.want)
+		}
+	}
+}
+
+func TestConsoleWrap(t *testing.T) {
+	var buf bytes.Buffer
+	c := newTestConsole(t, &buf, `{"color":false,"time_format":"15:04","wrap_column":20,"wrap_indent":4}`)
+	c.WriteMsg(time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC), "[I]  0123456789abcdefghij", LevelInfo)
+	want := "09:30  [I]  01234567\n    89abcdefghij\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestConsoleSecondTimeZone(t *testing.T) {
+	var buf bytes.Buffer
+	c := newTestConsole(t, &buf, `{"color":false,"time_format":"2006-01-02T15:04:05Z07:00","time_zone":"UTC","second_time_zone":"America/Los_Angeles"}`)
+	est := time.FixedZone("EST", -5*3600)
+	c.WriteMsg(time.Date(2024, 1, 15, 5, 0, 0, 0, est), "[I]  winter", LevelInfo)
+	c.WriteMsg(time.Date(2024, 7, 15, 10, 0, 0, 0, time.UTC), "[I]  summer", LevelInfo)
+	want := "2024-01-15T10:00:00Z (02:00 PST)  [I]  winter\n2024-07-15T10:00:00Z (03:00 PDT)  [I]  summer\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	for _, cfg := range []string{`{"time_zone":"Mars/Olympus"}`, `{"second_time_zone":"Nowhere/Invalid"}`} {
+		if err := NewConsole().Init(cfg); err == nil || !strings.Contains(err.Error(), "time_zone") {
+			t.Errorf("Init(%s) = %v", cfg, err)
+		}
+	}
+}
+
+func TestConsoleLevelAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	c := newTestConsole(t, &buf, `{"color":false,"time_format":"15:04","level_attrs":{"error":"bold","warn":"bold, underline"}}`)
+	when := time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC)
+	c.WriteMsg(when, "[E]  disk full", LevelError)
+	c.WriteMsg(when, "[W]  slow", LevelWarning)
+	c.WriteMsg(when, "[I]  plain", LevelInfo)
+	want := "09:30  \033[1m[E]\033[0m  disk full\n09:30  \033[1;4m[W]\033[0m  slow\n09:30  [I]  plain\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// 整行上色时同样加上属性，级别之后的部分仍是级别的颜色
+	buf.Reset()
+	c = newTestConsole(t, &buf, `{"color":true,"color_full_line":true,"time_format":"15:04","level_attrs":{"error":"bold"}}`)
+	c.WriteMsg(when, "[E]  disk full", LevelError)
+	want = "\033[1;31m09:30  \033[1m[E]\033[0m\033[1;31m  disk full\033[0m\033[0m\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	for _, cfg := range []string{`{"level_attrs":{"fatal":"bold"}}`, `{"level_attrs":{"error":"blink"}}`} {
+		if err := NewConsole().Init(cfg); err == nil {
+			t.Errorf("Init(%s) accepted", cfg)
+		}
+	}
+}
+
+// level_attrs 是 console 的配置，不会写进文件
+func TestLevelAttrsNotInFile(t *testing.T) {
+	inTempDir(t)
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	if err := al.AddLogger(AdapterFile, `{"filename":"app.log","color":false,"level_attrs":{"error":"bold"}}`); err != nil {
+		t.Fatal(err)
+	}
+	al.Error("disk full")
+	al.Close()
+	if got := readFile(t, "app.log"); strings.Contains(got, "\033") || !strings.HasSuffix(got, "[E]  disk full\n") {
+		t.Errorf("file got %q", got)
+	}
+}
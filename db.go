@@ -0,0 +1,206 @@
+package logs
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AdapterDB 写到数据库表，每条log一行，列为 time、level、message
+const AdapterDB = "db"
+
+// 缺省的批次大小
+const defaultDBBatchSize = 100
+
+var (
+	dbHandleLock sync.Mutex
+	dbHandle     *sql.DB
+)
+
+// SetDB 设置之后通过 AddLogger 创建的 db adapter 使用的连接，配置里给了 driver 和 dsn 时以配置为准；
+// 多个 AppLogger 要写到不同的连接时用 NewDBAdapter
+func SetDB(db *sql.DB) {
+	dbHandleLock.Lock()
+	dbHandle = db
+	dbHandleLock.Unlock()
+}
+
+type dbRow struct {
+	when  time.Time
+	level int
+	msg   string
+}
+
+// dbWriter 把log攒成批次，在一个事务里用预编译的 INSERT 写入
+type dbWriter struct {
+	sync.Mutex
+	db     *sql.DB
+	ownDB  bool // db 是按配置打开的，Destroy 时关闭
+	insert string
+	rows   []dbRow
+	err    error // 批次写入失败的错误，下一次 WriteMsg 时返回，Flush 之后由 AppLogger 通过 takeErr 取出
+
+	Table       string       `json:"table"`
+	Driver      string       `json:"driver"`
+	DSN         string       `json:"driver_dsn"`
+	Placeholder string       `json:"placeholder"` // 参数占位符的写法，"?"（缺省）或 "$"（如 PostgreSQL 的 $1）
+	BatchSize   int          `json:"batch_size"`
+	Level       adapterLevel `json:"level"`
+}
+
+// NewDB create a db writer using the handle set by SetDB.
+func NewDB() Logger {
+	dbHandleLock.Lock()
+	defer dbHandleLock.Unlock()
+	return NewDBAdapter(dbHandle)
+}
+
+// NewDBAdapter 返回写到 db 的 db adapter，不受 SetDB 影响，缺省写到 logs 表，可以直接用 AddAdapter 添加，
+// 也可以先用 Init 设置表名、批次大小等，配置同 AddLogger
+func NewDBAdapter(db *sql.DB) Logger {
+	d := &dbWriter{
+		db:        db,
+		Table:     "logs",
+		BatchSize: defaultDBBatchSize,
+		Level:     LevelDebug,
+	}
+	d.insert = d.insertSQL()
+	return d
+}
+
+// Init init db writer.
+// jsonConfig like '{"table":"logs","driver":"mysql","driver_dsn":"user:pass@/app","batch_size":100}'.
+func (d *dbWriter) Init(jsonConfig string) error {
+	if len(jsonConfig) > 0 {
+		if err := json.Unmarshal([]byte(jsonConfig), d); err != nil {
+			return err
+		}
+	}
+	if !isSQLIdentifier(d.Table) {
+		return fmt.Errorf("logs: invalid db table name %q", d.Table)
+	}
+	if d.Driver != "" || d.DSN != "" {
+		db, err := sql.Open(d.Driver, d.DSN)
+		if err != nil {
+			return err
+		}
+		d.db, d.ownDB = db, true
+	}
+	if d.db == nil {
+		return errors.New("logs: db handle is nil (forgotten SetDB or driver_dsn?)")
+	}
+	if d.BatchSize <= 0 {
+		d.BatchSize = defaultDBBatchSize
+	}
+	d.insert = d.insertSQL()
+	return nil
+}
+
+// insertSQL 按表名和占位符的写法生成 INSERT 语句
+func (d *dbWriter) insertSQL() string {
+	params := "?, ?, ?"
+	if d.Placeholder == "$" {
+		params = "$1, $2, $3"
+	}
+	return "INSERT INTO " + d.Table + " (time, level, message) VALUES (" + params + ")"
+}
+
+// isSQLIdentifier 表名只允许字母、数字、下划线和 schema 分隔用的 '.'，避免拼接 SQL 时被注入
+func isSQLIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	return strings.IndexFunc(name, func(r rune) bool {
+		return !(r == '_' || r == '.' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) < 0
+}
+
+// WriteMsg add message to the current batch, inserting it when the batch is full.
+func (d *dbWriter) WriteMsg(when time.Time, msg string, level int) error {
+	if level > d.Level.get() {
+		return nil
+	}
+	d.Lock()
+	defer d.Unlock()
+
+	d.rows = append(d.rows, dbRow{when, level, msg})
+	if len(d.rows) >= d.BatchSize {
+		d.insertRows()
+	}
+	err := d.err
+	d.err = nil
+	return err
+}
+
+// insertRows 在一个事务里写入当前批次，失败时整批回滚并丢弃；调用时需持有锁
+func (d *dbWriter) insertRows() {
+	if len(d.rows) == 0 {
+		return
+	}
+	if err := d.insertTx(d.rows); err != nil {
+		d.err = fmt.Errorf("logs: db insert dropped %d rows: %v", len(d.rows), err)
+	}
+	d.rows = d.rows[:0]
+}
+
+func (d *dbWriter) insertTx(rows []dbRow) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(d.insert)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	for _, r := range rows {
+		if _, err := stmt.Exec(r.when, r.level, r.msg); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// takeErr 返回并清除批次写入失败的错误，用于报告 Flush 和 Destroy 里写入的最后一批
+func (d *dbWriter) takeErr() error {
+	d.Lock()
+	defer d.Unlock()
+	err := d.err
+	d.err = nil
+	return err
+}
+
+// GetLevel return the level of this writer.
+func (d *dbWriter) GetLevel() int {
+	return d.Level.get()
+}
+
+// SetLevel set the level of this writer.
+func (d *dbWriter) SetLevel(level int) {
+	d.Level.set(level)
+}
+
+// Destroy insert the remaining rows and close the handle opened from the config.
+func (d *dbWriter) Destroy() {
+	d.Flush()
+	if d.ownDB {
+		d.db.Close()
+	}
+}
+
+// Flush insert the current batch.
+func (d *dbWriter) Flush() {
+	d.Lock()
+	d.insertRows()
+	d.Unlock()
+}
+
+func init() {
+	Register(AdapterDB, NewDB)
+}
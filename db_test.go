@@ -0,0 +1,84 @@
+package logs
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewDBAdapter(t *testing.T) {
+	dbA, dataA := openFakeDB(t, "a")
+	dbB, dataB := openFakeDB(t, "b")
+	a, b := NewAppLogger(), NewAppLogger()
+	a.RemoveLogger(AdapterConsole)
+	b.RemoveLogger(AdapterConsole)
+	if err := a.AddAdapter(AdapterDB, NewDBAdapter(dbA)); err != nil {
+		t.Fatal(err)
+	}
+	lg := NewDBAdapter(dbB)
+	if err := lg.Init(`{"table":"app_logs","batch_size":2,"placeholder":"$"}`); err != nil {
+		t.Fatal(err)
+	}
+	if got := lg.(*dbWriter).insert; got != "INSERT INTO app_logs (time, level, message) VALUES ($1, $2, $3)" {
+		t.Errorf("insert = %q", got)
+	}
+	if err := b.AddAdapter(AdapterDB, lg); err != nil {
+		t.Fatal(err)
+	}
+
+	a.Info("to a")
+	b.Info("to b 1")
+	b.Info("to b 2")
+	// b 的批次满了，已经写入
+	if got := dataB.messages(); len(got) != 2 {
+		t.Errorf("b has %q before Close", got)
+	}
+	if got := dataA.messages(); len(got) != 0 {
+		t.Errorf("a has %q before Flush", got)
+	}
+	a.Close()
+	b.Close()
+	if got := dataA.messages(); len(got) != 1 || !contains(got, "to a") {
+		t.Errorf("a got %q", got)
+	}
+	if got := dataB.messages(); len(got) != 2 || contains(got, "to a") {
+		t.Errorf("b got %q", got)
+	}
+}
+
+func TestDBAdapterInit(t *testing.T) {
+	db, _ := openFakeDB(t, "init")
+	if err := NewDBAdapter(db).Init(`{"table":"logs; DROP TABLE users"}`); err == nil {
+		t.Error("accepted an invalid table name")
+	}
+	if err := NewDBAdapter(nil).Init(`{}`); err == nil {
+		t.Error("Init succeeded without a handle")
+	}
+}
+
+// 报告 Flush 和 Close 时写入的最后一批的错误
+func TestDBFinalBatchError(t *testing.T) {
+	db, data := openFakeDB(t, "failing")
+	data.setErr(errors.New("disk full"))
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	al.AddAdapter(AdapterDB, NewDBAdapter(db))
+	al.SetAuditMode(true)
+	var reported []error
+	al.SetErrorHandler(func(name string, err error) {
+		reported = append(reported, err)
+	})
+
+	al.Info("flushed")
+	al.Flush()
+	if len(reported) != 1 || !strings.Contains(reported[0].Error(), "dropped 1 rows: disk full") {
+		t.Errorf("reported %v after Flush", reported)
+	}
+	al.Info("closed")
+	if err := al.CloseErr(); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("CloseErr() = %v", err)
+	}
+	if len(reported) != 2 {
+		t.Errorf("reported %v", reported)
+	}
+}
@@ -0,0 +1,138 @@
+package logs
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Entry 一条log，有两种用法：
+//
+// 由 WithFields 从池里取出，带着 Fields 调用 Info、Warn、Debug、Error 或 Log。这样的 Entry 只能用一次，
+// 调用之后就被放回池里给别的调用复用，之后不能再使用，也不能保存或者传给其他 goroutine，否则会写出别人的字段。
+//
+// 由调用方直接构造，填好 Level、When、Msg 和 Fields，交给 LogBatch 批量写出
+type Entry struct {
+	Level  int
+	When   time.Time // 为零值时使用写出时的时间
+	Msg    string
+	Fields []Field
+
+	al *AppLogger
+}
+
+// 字段数超过这个值的 Entry 不放回池里，避免一次字段很多的调用之后池里一直留着很大的 slice
+const maxPooledEntryFields = 64
+
+var entryPool = sync.Pool{
+	New: func() interface{} {
+		return &Entry{Fields: make([]Field, 0, 8)}
+	},
+}
+
+// WithFields 返回带着 fields 的 Entry，用法如 al.WithFields(logs.String("user", id)).Info("login")，
+// 返回的 Entry 在写完一次log之后失效
+func (al *AppLogger) WithFields(fields ...Field) *Entry {
+	e := entryPool.Get().(*Entry)
+	e.al = al
+	e.Fields = append(e.Fields, fields...)
+	return e
+}
+
+// With 追加字段，返回 e 本身
+func (e *Entry) With(fields ...Field) *Entry {
+	e.Fields = append(e.Fields, fields...)
+	return e
+}
+
+// release 清空 e 并放回池里
+func (e *Entry) release() {
+	if cap(e.Fields) > maxPooledEntryFields {
+		return
+	}
+	for i := range e.Fields {
+		e.Fields[i] = Field{}
+	}
+	*e = Entry{Fields: e.Fields[:0]}
+	entryPool.Put(e)
+}
+
+// Log 同 AppLogger.Log，字段放在 fields 前面
+func (e *Entry) Log(level int, msg string, fields ...Field) {
+	defer e.release()
+	if !e.al.Enabled(level) {
+		return
+	}
+	e.al.writeMsg(level, append(e.Fields, fields...), msg)
+}
+
+func (e *Entry) Info(format string, v ...interface{}) {
+	defer e.release()
+	if !e.al.Enabled(LevelInfo) {
+		return
+	}
+	e.al.guardFormat(format, v)
+	e.al.writeMsg(LevelInfo, e.Fields, format, v...)
+}
+
+func (e *Entry) Warn(format string, v ...interface{}) {
+	defer e.release()
+	if !e.al.Enabled(LevelWarning) {
+		return
+	}
+	e.al.guardFormat(format, v)
+	e.al.writeMsg(LevelWarning, e.Fields, format, v...)
+}
+
+func (e *Entry) Debug(format string, v ...interface{}) {
+	defer e.release()
+	if !e.al.Enabled(LevelDebug) {
+		return
+	}
+	e.al.guardFormat(format, v)
+	e.al.writeMsg(LevelDebug, e.Fields, format, v...)
+}
+
+func (e *Entry) Error(format string, v ...interface{}) {
+	defer e.release()
+	if !e.al.Enabled(LevelError) {
+		return
+	}
+	e.al.guardFormat(format, v)
+	e.al.writeMsg(LevelError, e.Fields, format, v...)
+}
+
+// LogBatch 按顺序写出 entries，整批只获取一次锁，适合导入或回放大量log；
+// 每条按自己的 Level 过滤，When 不为零值时作为log的时间。entries 在返回之后可以复用
+func (al *AppLogger) LogBatch(entries []Entry) {
+	// 整批只获取一次 drainGate，逐条生成 Record 后直接写出，不再另外分配一份 Record
+	al.drainGate.RLock()
+	defer al.drainGate.RUnlock()
+	closed := atomic.LoadInt32(&al.closed) != 0
+	for i := range entries {
+		r, ok := al.batchRecord(&entries[i])
+		if !ok {
+			continue
+		}
+		if closed {
+			al.writeFallback(&r)
+			continue
+		}
+		al.dispatchLocked(r, nil)
+	}
+}
+
+// batchRecord 生成 e 的 Record，ok 为 false 表示不需要写出；只能由 LogBatch 直接调用，否则调用位置会算错
+func (al *AppLogger) batchRecord(e *Entry) (r Record, ok bool) {
+	if !al.Enabled(e.Level) || !al.moduleAllows(0, e.Level) {
+		return r, false
+	}
+	if e.Msg == "" && len(e.Fields) == 0 {
+		return r, false
+	}
+	r = al.newRecord(0, e.Level, e.Fields, e.Msg, nil)
+	if !e.When.IsZero() {
+		r.When = al.stamp(e.When, r.precision)
+	}
+	return r, true
+}
@@ -0,0 +1,142 @@
+package logs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestWithFields(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.WithFields(String("user", "u1")).With(Int("attempt", 2)).Warn("login %s", "failed")
+	// 放回池里的 Entry 不带着上一次的字段
+	al.WithFields().Info("plain")
+	al.SetLevel(LevelInfo)
+	al.WithFields(String("k", "v")).Debug("hidden")
+	al.WithFields(String("k", "v")).Log(LevelError, "logged", Bool("extra", true))
+
+	records := c.all()
+	if len(records) != 3 {
+		t.Fatalf("got %q", c.lines())
+	}
+	if r := records[0]; r.Level != LevelWarning || r.Msg != "login failed" || r.Fields["user"] != "u1" || r.Fields["attempt"] != 2 {
+		t.Errorf("got %+v", r)
+	}
+	if r := records[1]; len(r.Fields) != 0 {
+		t.Errorf("reused entry kept fields %v", r.Fields)
+	}
+	if r := records[2]; r.Fields["k"] != "v" || r.Fields["extra"] != true {
+		t.Errorf("got %+v", r)
+	}
+}
+
+func newBenchLogger() *AppLogger {
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	al.AddWriter("discard", ioutil.Discard, LevelDebug)
+	return al
+}
+
+func BenchmarkWithFields(b *testing.B) {
+	al := newBenchLogger()
+	defer al.Close()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		al.WithFields(String("user", "u1"), Int("status", 200)).Info("request handled")
+	}
+}
+
+func BenchmarkLogFields(b *testing.B) {
+	al := newBenchLogger()
+	defer al.Close()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		al.Log(LevelInfo, "request handled", String("user", "u1"), Int("status", 200))
+	}
+}
+
+func TestLogBatch(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.SetLevel(LevelInfo)
+	when := time.Date(2024, 3, 9, 14, 5, 6, 0, time.UTC)
+	entries := []Entry{
+		{Level: LevelWarning, When: when, Msg: "first"},
+		{Level: LevelDebug, Msg: "filtered"},
+		{Level: LevelInfo, Msg: "second", Fields: []Field{Int("n", 2)}},
+		{Level: LevelInfo},
+		{Level: LevelError, When: when.Add(time.Second), Msg: "third"},
+	}
+	before := time.Now()
+	al.LogBatch(entries)
+
+	records := c.all()
+	want := []string{"[W]  first", "[I]  second n=2", "[E]  third"}
+	if len(records) != len(want) {
+		t.Fatalf("got %q", c.lines())
+	}
+	for i, w := range want {
+		if records[i].text != w {
+			t.Errorf("record %d = %q, want %q", i, records[i].text, w)
+		}
+	}
+	if !records[0].When.Equal(when) || !records[2].When.Equal(when.Add(time.Second)) {
+		t.Errorf("times %v, %v", records[0].When, records[2].When)
+	}
+	if records[1].When.Before(before) {
+		t.Errorf("zero When not replaced: %v", records[1].When)
+	}
+}
+
+func TestLogBatchAsync(t *testing.T) {
+	al, c := newTestLogger(t)
+	al.Async()
+	entries := make([]Entry, 100)
+	for i := range entries {
+		entries[i] = Entry{Level: LevelInfo, Msg: fmt.Sprint("m", i)}
+	}
+	al.LogBatch(entries)
+	al.Close()
+	lines := c.lines()
+	if len(lines) != len(entries) {
+		t.Fatalf("%d of %d written", len(lines), len(entries))
+	}
+	for i, l := range lines {
+		if want := fmt.Sprint("[I]  m", i); l != want {
+			t.Fatalf("line %d = %q, want %q", i, l, want)
+		}
+	}
+}
+
+func benchEntries() []Entry {
+	entries := make([]Entry, 100)
+	for i := range entries {
+		entries[i] = Entry{Level: LevelInfo, Msg: "replayed record", Fields: []Field{Int("n", i)}}
+	}
+	return entries
+}
+
+func BenchmarkLogBatch(b *testing.B) {
+	al := newBenchLogger()
+	defer al.Close()
+	entries := benchEntries()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		al.LogBatch(entries)
+	}
+}
+
+// 同样的 entries 逐条调用 Log，与 BenchmarkLogBatch 对比
+func BenchmarkLogBatchLoop(b *testing.B) {
+	al := newBenchLogger()
+	defer al.Close()
+	entries := benchEntries()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, e := range entries {
+			al.Log(e.Level, e.Msg, e.Fields...)
+		}
+	}
+}
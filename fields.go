@@ -0,0 +1,275 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Field 一个结构化字段，由 String、Int、Err 等函数生成，传给 Log
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// String 字符串字段
+func String(key, value string) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Int 整数字段
+func Int(key string, value int) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Int64 64位整数字段
+func Int64(key string, value int64) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Float64 浮点数字段
+func Float64(key string, value float64) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Bool 布尔字段
+func Bool(key string, value bool) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Duration 时间长度字段
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Err 错误字段，key 为 "error"，值为 err.Error()，err 为 nil 时值为 nil
+func Err(err error) Field {
+	if err == nil {
+		return Field{Key: "error", Value: nil}
+	}
+	return Field{Key: "error", Value: err.Error()}
+}
+
+// Any 任意类型的字段
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Log 以 level 级别写 msg，fields 按顺序以 "k=v" 的形式追加在消息后面
+func (al *AppLogger) Log(level int, msg string, fields ...Field) {
+	if !al.Enabled(level) {
+		return
+	}
+	al.writeMsg(level, fields, msg)
+}
+
+// SetBaseFields 设置每条log都带上的字段，如 service=checkout env=prod，按 key 排序放在每次调用传入的字段前面；
+// 和调用时传入的字段重名时以调用时的为准
+func (al *AppLogger) SetBaseFields(kv map[string]interface{}) {
+	keys := make([]string, 0, len(kv))
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fields := make([]Field, len(keys))
+	for i, k := range keys {
+		fields[i] = Field{Key: k, Value: kv[k]}
+	}
+	al.lock.Lock()
+	al.baseFields = fields
+	al.lock.Unlock()
+}
+
+// withBaseFields 返回基础字段和 fields 合并后的字段，重名的基础字段被去掉
+func (al *AppLogger) withBaseFields(fields []Field) []Field {
+	al.lock.Lock()
+	base := al.baseFields
+	al.lock.Unlock()
+	if len(base) == 0 {
+		return fields
+	}
+	merged := make([]Field, 0, len(base)+len(fields))
+	for _, b := range base {
+		overridden := false
+		for _, f := range fields {
+			if f.Key == b.Key {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			merged = append(merged, b)
+		}
+	}
+	return append(merged, fields...)
+}
+
+// 字段的2种写法，由 SetFieldStyle 选择
+const (
+	FieldStyleEquals = iota // key=value
+	FieldStyleColon         // key:value
+)
+
+// fieldFormat 字段之间的分隔符和 key 与值之间的符号，零值为 "k=v k=v"
+type fieldFormat struct {
+	sep    string
+	assign string
+}
+
+// formatFields 把 fields 按顺序拼成 "k=v k=v"，nil 写成 null
+func formatFields(fields []Field) string {
+	return fieldFormat{}.format(fields)
+}
+
+// format 同 formatFields，按 ff 的分隔符和写法
+func (ff fieldFormat) format(fields []Field) string {
+	sep, assign := ff.sep, ff.assign
+	if sep == "" {
+		sep = " "
+	}
+	if assign == "" {
+		assign = "="
+	}
+	pairs := make([]string, len(fields))
+	for i, f := range fields {
+		if f.Value == nil {
+			pairs[i] = f.Key + assign + "null"
+			continue
+		}
+		if o, ok := f.Value.(objectValue); ok {
+			// InfoObj 的对象直接展开，不写 key
+			pairs[i] = o.String()
+			continue
+		}
+		pairs[i] = f.Key + assign + fmt.Sprint(f.Value)
+	}
+	return strings.Join(pairs, sep)
+}
+
+// join 用分隔符连接 parts
+func (ff fieldFormat) join(parts []string) string {
+	if ff.sep == "" {
+		return strings.Join(parts, " ")
+	}
+	return strings.Join(parts, ff.sep)
+}
+
+// SetFieldSeparator 设置字段之间、前缀栈的各个前缀之间的分隔符，如 "\t" 或 " | "，为空时恢复为空格
+func (al *AppLogger) SetFieldSeparator(sep string) {
+	al.lock.Lock()
+	al.fieldFormat.sep = sep
+	al.lock.Unlock()
+}
+
+// SetFieldStyle 设置字段的写法：FieldStyleEquals（缺省）或 FieldStyleColon
+func (al *AppLogger) SetFieldStyle(style int) error {
+	assign := ""
+	switch style {
+	case FieldStyleEquals:
+		assign = "="
+	case FieldStyleColon:
+		assign = ":"
+	default:
+		return fmt.Errorf("logs: unknown field style %d", style)
+	}
+	al.lock.Lock()
+	al.fieldFormat.assign = assign
+	al.lock.Unlock()
+	return nil
+}
+
+func (al *AppLogger) getFieldFormat() fieldFormat {
+	al.lock.Lock()
+	defer al.lock.Unlock()
+	return al.fieldFormat
+}
+
+// InfoObj 以 Info 级别写log，obj 为 struct 或 map 时按 key 排序后以 "a=1 b=2" 的形式追加在 msg 后面，
+// JSON 输出中作为嵌套的对象放在 object 字段；struct 未导出的字段会被跳过，nil 写成 null
+func (al *AppLogger) InfoObj(msg string, obj interface{}) {
+	if !al.Enabled(LevelInfo) {
+		return
+	}
+	al.writeMsg(LevelInfo, []Field{{Key: objectFieldKey, Value: objectValue{obj}}}, msg)
+}
+
+// InfoObj 的对象在 JSON 输出中的字段名
+const objectFieldKey = "object"
+
+// objectValue InfoObj 的对象，文本中展开为 formatObject 的结果，JSON 中编码为嵌套的对象
+type objectValue struct {
+	obj interface{}
+}
+
+func (o objectValue) String() string {
+	return formatObject(o.obj)
+}
+
+// MarshalJSON 按 encoding/json 的规则编码对象，无法编码时（如 map 的 key 是 struct）使用文本的写法
+func (o objectValue) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(o.obj)
+	if err != nil {
+		return json.Marshal(o.String())
+	}
+	return b, nil
+}
+
+// formatObject 把 struct 或 map 展开为按 key 排序的 "k=v" 列表，其它类型直接用 fmt.Sprint
+func formatObject(obj interface{}) string {
+	v := reflect.ValueOf(obj)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "null"
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return "null"
+	}
+
+	var keys []string
+	values := make(map[string]string)
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			keys = append(keys, f.Name)
+			values[f.Name] = formatValue(v.Field(i))
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			return "null"
+		}
+		for _, k := range v.MapKeys() {
+			key := fmt.Sprint(k.Interface())
+			keys = append(keys, key)
+			values[key] = formatValue(v.MapIndex(k))
+		}
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + values[k]
+	}
+	return strings.Join(pairs, " ")
+}
+
+func formatValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			return "null"
+		}
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,156 @@
+package logs
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type objTest struct {
+	B      int
+	A      string
+	hidden int
+	Nested map[string]int
+}
+
+func TestInfoObj(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.InfoObj("struct", objTest{B: 2, A: "x", hidden: 3})
+	al.InfoObj("map", map[string]interface{}{"b": 2, "a": 1, "n": nil})
+	al.InfoObj("nil", nil)
+	al.InfoObj("ptr", (*objTest)(nil))
+
+	want := []string{
+		"struct A=x B=2 Nested=null",
+		"map a=1 b=2 n=null",
+		"nil null",
+		"ptr null",
+	}
+	lines := c.lines()
+	if len(lines) != len(want) {
+		t.Fatalf("got %q", lines)
+	}
+	for i, w := range want {
+		if !strings.HasSuffix(lines[i], w) {
+			t.Errorf("line %d = %q, want suffix %q", i, lines[i], w)
+		}
+	}
+}
+
+func TestInfoObjJSON(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.InfoObj("req", objTest{B: 2, A: "<x>", hidden: 3, Nested: map[string]int{"k": 1}})
+	al.InfoObj("odd keys", map[struct{ X int }]int{{1}: 1})
+
+	records := c.all()
+	b, err := jsonRecord(&records[0], false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Msg    string `json:"msg"`
+		Object map[string]interface{}
+	}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Msg != "req" || got.Object["A"] != "<x>" || got.Object["B"] != 2.0 || got.Object["hidden"] != nil {
+		t.Errorf("got %s", b)
+	}
+	if nested, _ := got.Object["Nested"].(map[string]interface{}); nested["k"] != 1.0 {
+		t.Errorf("nested object not encoded as JSON: %s", b)
+	}
+
+	// 不能编码成 JSON 的对象使用文本的写法
+	if b, err = jsonRecord(&records[1], true); err != nil || !strings.Contains(string(b), `"object":"{1}=1"`) {
+		t.Errorf("got %s, %v", b, err)
+	}
+}
+
+func TestLogFields(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.Log(LevelInfo, "request",
+		String("method", "GET"), Int("status", 200), Int64("bytes", 512), Float64("ratio", 0.5),
+		Bool("cached", true), Duration("took", 1500*time.Millisecond), Err(errors.New("eof")), Err(nil), Any("tags", []string{"a", "b"}))
+	al.SetLevel(LevelInfo)
+	al.Log(LevelDebug, "hidden", String("k", "v"))
+
+	lines := c.lines()
+	if len(lines) != 1 {
+		t.Fatalf("got %q", lines)
+	}
+	want := "request method=GET status=200 bytes=512 ratio=0.5 cached=true took=1.5s error=eof error=null tags=[a b]"
+	if !strings.HasSuffix(lines[0], want) {
+		t.Errorf("got %q, want suffix %q", lines[0], want)
+	}
+	if f := c.all()[0].Fields; f["status"] != 200 || f["took"] != 1500*time.Millisecond {
+		t.Errorf("fields %v", f)
+	}
+}
+
+func TestSetBaseFields(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.SetBaseFields(map[string]interface{}{"service": "checkout", "env": "prod"})
+	al.Info("plain")
+	al.Log(LevelInfo, "override", String("env", "staging"), Int("n", 1))
+	al.SetBaseFields(nil)
+	al.Info("cleared")
+
+	want := []string{
+		"plain env=prod service=checkout",
+		"override service=checkout env=staging n=1",
+		"cleared",
+	}
+	lines := c.lines()
+	for i, w := range want {
+		if !strings.HasSuffix(lines[i], w) {
+			t.Errorf("line %d = %q, want suffix %q", i, lines[i], w)
+		}
+	}
+	if f := c.all()[1].Fields; f["env"] != "staging" || f["service"] != "checkout" {
+		t.Errorf("fields %v", f)
+	}
+}
+
+func TestFieldSeparatorAndStyle(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.SetBaseFields(map[string]interface{}{"service": "checkout"})
+	pop := al.PushPrefix("[svc]")
+	al.PushPrefix("[handler]")
+	al.SetFieldSeparator(" | ")
+	if err := al.SetFieldStyle(FieldStyleColon); err != nil {
+		t.Fatal(err)
+	}
+	al.Log(LevelInfo, "done", Int("status", 200), String("user", "u1"))
+	pop()
+	al.SetFieldSeparator("\t")
+	al.SetFieldStyle(FieldStyleEquals)
+	al.Log(LevelInfo, "tabbed", Int("n", 1))
+	al.SetFieldSeparator("")
+	al.Log(LevelInfo, "default", Int("n", 2))
+	if err := al.SetFieldStyle(7); err == nil {
+		t.Error("accepted an unknown style")
+	}
+
+	want := []string{
+		"[svc] | [handler] done service:checkout | status:200 | user:u1",
+		"tabbed service=checkout\tn=1",
+		"default service=checkout n=2",
+	}
+	lines := c.lines()
+	if len(lines) != len(want) {
+		t.Fatalf("got %q", lines)
+	}
+	for i, w := range want {
+		if !strings.HasSuffix(lines[i], w) {
+			t.Errorf("line %d = %q, want suffix %q", i, lines[i], w)
+		}
+	}
+}
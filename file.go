@@ -11,12 +11,42 @@ import (
 
 
 type fileWriter struct {
+	colorTheme
 	lg  *logWriter
+	levelLg [LevelDebug + 1]*logWriter // Files 中配置了单独文件的级别
+	files map[string]*os.File          // 所有打开的文件，按文件名
+	zones timeZones
+	starting bool // 正在 Init 中打开文件
+	namer RotateNamer // 切分时归档文件的命名方式，见 SetRotateNamer
 	FileName string    `json:"filename"`
-	Level int			`json:"level"`
+	Level adapterLevel			`json:"level"`
 	Colorful bool  		`json:"color"`
+	// Append 为 false 时 Init 以 O_TRUNC 打开文件，每次运行都从空文件开始；
+	// 只影响 Init 时打开文件的方式，与之后文件如何切分无关
+	Append bool `json:"append"`
+	NoNewline bool `json:"no_newline"`
+	TimeFormat string `json:"time_format"` // 同 console
+	TimeZone       string `json:"time_zone"` // 同 console
+	SecondTimeZone string `json:"second_time_zone"`
+	// OpenRetries 打开文件失败后的重试次数，每次重试前等待的时间翻倍
+	OpenRetries int `json:"open_retries"`
+	// Files 按级别名字把log写到单独的文件，如 {"error":"err.log","info":"info.log"}，没有配置的级别写到 FileName
+	Files map[string]string `json:"files"`
+	// Header 不为空时，打开的文件是空文件（新建或者被清空）时先写这一行，如 "#logformat=text v1 fields=time,level,msg"，
+	// 追加到已有内容的文件时不写
+	Header string `json:"header"`
+	// SessionSeparator 为 true 时，Init 追加到已有内容的文件时先写一行 "--- new session <时间> ---"，把每次运行的log分开
+	SessionSeparator bool `json:"session_separator"`
+	// FileLock 为 true 时每次写入都持有文件的 flock 排他锁，多个进程追加同一个文件时较长的行也不会交错；
+	// 需要同时开启 Append，只在支持 flock 的系统上有效
+	FileLock bool `json:"flock"`
+	JSON       bool `json:"json"`        // 每行输出一个 JSON 对象
+	EscapeHTML bool `json:"escape_html"` // JSON 输出时是否把 <>& 转义为 \u003c 这种形式
 }
 
+// 第一次重试前等待的时间
+const fileOpenBackoff = 100 * time.Millisecond
+
 
 
 func NewFile() Logger {
@@ -26,9 +56,11 @@ func NewFile() Logger {
 	}
 	return &fileWriter{
 		lg : newLogWriter(file),
+		files: map[string]*os.File{"default.log": file},
 		FileName: "default.log",
 		Level: LevelDebug,
 		Colorful: true,
+		Append: true,
 	}
 
 }
@@ -43,34 +75,187 @@ func (f *fileWriter) Init(jsonConfig string) error {
 		return err
 	}
 
-	logfile ,err := os.OpenFile(f.FileName,os.O_APPEND|os.O_WRONLY|os.O_CREATE,0644)
+	if f.zones, err = loadTimeZones(f.TimeZone, f.SecondTimeZone); err != nil {
+		return err
+	}
+
+	f.closeFiles()
+	f.starting = true
+	defer func() { f.starting = false }()
+	return f.openFiles(f.Append)
+}
+
+// Reopen 关闭并以追加方式重新打开所有文件，用于 logrotate 等外部工具移走文件之后
+func (f *fileWriter) Reopen() error {
+	f.closeFiles()
+	return f.openFiles(true)
+}
+
+// openFiles 打开 FileName 和 Files 中配置的文件，appendMode 为 false 时清空已有内容
+func (f *fileWriter) openFiles(appendMode bool) error {
+	var err error
+	f.lg, err = f.openLogWriter(f.FileName, appendMode)
 	if err != nil {
 		return err
 	}
-	f.lg = newLogWriter(logfile)
+	for name, filename := range f.Files {
+		level, ok := levelNames[name]
+		if !ok {
+			return fmt.Errorf("logs: unknown level %q in files config", name)
+		}
+		f.levelLg[level], err = f.openLogWriter(filename, appendMode)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// openLogWriter 返回写 filename 的 logWriter，同一个文件只打开一次
+func (f *fileWriter) openLogWriter(filename string, appendMode bool) (*logWriter, error) {
+	logfile, ok := f.files[filename]
+	if !ok {
+		var err error
+		logfile, err = f.openFile(filename, appendMode)
+		if err != nil {
+			return nil, err
+		}
+		if err = f.writeHeader(logfile); err != nil {
+			logfile.Close()
+			return nil, err
+		}
+		f.files[filename] = logfile
+	}
+	for _, lg := range append(f.levelLg[:], f.lg) {
+		if lg != nil && lg.writer == logfile {
+			return lg, nil
+		}
+	}
+	lg := newLogWriter(logfile)
+	lg.noNewline = f.NoNewline
+	lg.timeFormat = f.TimeFormat
+	lg.zones = f.zones
+	lg.fileLock = f.FileLock
+	return lg, nil
+}
+
+// openFile 打开 filename，失败时按 OpenRetries 退避重试，重试用完后返回最后一次的错误
+func (f *fileWriter) openFile(filename string, appendMode bool) (*os.File, error) {
+	flag := os.O_APPEND
+	if !appendMode {
+		flag = os.O_TRUNC
+	}
+	backoff := fileOpenBackoff
+	for i := 0; ; i++ {
+		logfile, err := os.OpenFile(filename, flag|os.O_WRONLY|os.O_CREATE, 0644)
+		if err == nil || i >= f.OpenRetries {
+			return logfile, err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// writeHeader logfile 为空时写出 Header，Init 时 logfile 不为空则按 SessionSeparator 写出分隔行
+func (f *fileWriter) writeHeader(logfile *os.File) error {
+	if f.Header == "" && !f.SessionSeparator {
+		return nil
+	}
+	info, err := logfile.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		if f.Header != "" {
+			_, err = logfile.WriteString(strings.TrimSuffix(f.Header, "\n") + "\n")
+		}
+		return err
+	}
+	if f.SessionSeparator && f.starting {
+		_, err = logfile.WriteString("--- new session " + time.Now().Format(layout) + " ---\n")
+	}
+	return err
+}
+
+// closeFiles 关闭所有打开的文件
+func (f *fileWriter) closeFiles() {
+	for name, logfile := range f.files {
+		if logfile != nil {
+			logfile.Close()
+		}
+		delete(f.files, name)
+	}
+	f.lg = nil
+	f.levelLg = [LevelDebug + 1]*logWriter{}
+}
+
 // WriteMsg write message in console.
 func (f *fileWriter) WriteMsg(when time.Time, msg string, level int) error {
-	if level > f.Level {
+	return f.writeText(when, 0, msg, level)
+}
+
+func (f *fileWriter) writeText(when time.Time, precision time.Duration, msg string, level int) error {
+	if level > f.Level.get() {
 		return nil
 	}
 	if f.Colorful {
-		msg = strings.Replace(msg, levelPrefix[level], colors[level](levelPrefix[level]), 1)
+		msg = f.palette().colorLevelPrefix(msg, level)
 	}
-	f.lg.writeln(when, msg)
-	return nil
+	_, err := f.levelWriter(level).writeLine(when, precision, msg, nil)
+	return err
 }
 
-// Destroy implementing method. empty.
+// levelWriter 返回写 level 级别log的 logWriter
+func (f *fileWriter) levelWriter(level int) *logWriter {
+	if validLevel(level) && f.levelLg[level] != nil {
+		return f.levelLg[level]
+	}
+	return f.lg
+}
+
+// WriteRecord write record as a JSON line when json is enabled, otherwise same as WriteMsg.
+func (f *fileWriter) WriteRecord(r *Record) error {
+	if !f.JSON {
+		return f.writeText(r.When, r.precision, r.String(), r.Level)
+	}
+	if r.Level > f.Level.get() {
+		return nil
+	}
+	_, err := f.levelWriter(r.Level).writeJSON(r, f.EscapeHTML)
+	return err
+}
+
+// WriteRaw write the bytes produced by the AppLogger's Formatter.
+func (f *fileWriter) WriteRaw(p []byte, level int) error {
+	if level > f.Level.get() {
+		return nil
+	}
+	_, err := f.levelWriter(level).writeRaw(p)
+	return err
+}
+
+// GetLevel return the level of this writer.
+func (f *fileWriter) GetLevel() int {
+	return f.Level.get()
+}
+
+// SetLevel set the level of this writer.
+func (f *fileWriter) SetLevel(level int) {
+	f.Level.set(level)
+}
+
+// Destroy close all opened files.
 func (f *fileWriter) Destroy() {
-	
+	f.closeFiles()
 }
 
-// Flush implementing method. empty.
+// Flush sync all opened files to disk.
 func (f *fileWriter) Flush() {
-
+	for _, logfile := range f.files {
+		if logfile != nil {
+			logfile.Sync()
+		}
+	}
 }
 
 func init() {
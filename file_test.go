@@ -0,0 +1,175 @@
+package logs
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readFile 返回 name 的内容，读取失败时结束测试
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// runFileLogger 用 jsonConfig 添加 file Logger，写一条 Info 后关闭
+func runFileLogger(t *testing.T, jsonConfig, msg string) {
+	t.Helper()
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	if err := al.AddLogger(AdapterFile, jsonConfig); err != nil {
+		t.Fatal(err)
+	}
+	al.Info("%s", msg)
+	al.Close()
+}
+
+func TestFileAppendMode(t *testing.T) {
+	inTempDir(t)
+	if err := ioutil.WriteFile("app.log", []byte("old run\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	runFileLogger(t, `{"filename":"app.log","append":true}`, "appended")
+	if got := readFile(t, "app.log"); !strings.HasPrefix(got, "old run\n") || !strings.Contains(got, "appended") {
+		t.Errorf("append mode got %q", got)
+	}
+
+	runFileLogger(t, `{"filename":"app.log","append":false}`, "new run")
+	if got := readFile(t, "app.log"); strings.Contains(got, "old run") || strings.Contains(got, "appended") || !strings.Contains(got, "new run") {
+		t.Errorf("new-file mode got %q", got)
+	}
+}
+
+func TestFileOpenRetries(t *testing.T) {
+	inTempDir(t)
+	if err := NewFile().Init(`{"filename":"missing/app.log"}`); err == nil {
+		t.Fatal("opened a file in a missing directory")
+	}
+
+	// 目录在第一次重试之前出现
+	go func() {
+		time.Sleep(fileOpenBackoff / 4)
+		os.Mkdir("later", 0755)
+	}()
+	lg := NewFile()
+	if err := lg.Init(`{"filename":"later/app.log","open_retries":2}`); err != nil {
+		t.Fatalf("Init with retries: %v", err)
+	}
+	lg.Destroy()
+
+	start := time.Now()
+	if err := NewFile().Init(`{"filename":"never/app.log","open_retries":2}`); err == nil {
+		t.Fatal("opened a file in a missing directory")
+	}
+	// 重试前等待 100ms 和 200ms
+	if d := time.Since(start); d < 3*fileOpenBackoff {
+		t.Errorf("gave up after %v", d)
+	}
+}
+
+func TestFilePerLevelFiles(t *testing.T) {
+	inTempDir(t)
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	if err := al.AddLogger(AdapterFile, `{"filename":"app.log","color":false,"files":{"error":"err.log","warn":"err.log"}}`); err != nil {
+		t.Fatal(err)
+	}
+	al.Error("disk failed")
+	al.Warn("disk slow")
+	al.Info("request served")
+	al.Close()
+
+	errLog, appLog := readFile(t, "err.log"), readFile(t, "app.log")
+	if !strings.Contains(errLog, "disk failed") || !strings.Contains(errLog, "disk slow") || strings.Contains(errLog, "request served") {
+		t.Errorf("err.log got %q", errLog)
+	}
+	if !strings.Contains(appLog, "request served") || strings.Contains(appLog, "disk") {
+		t.Errorf("app.log got %q", appLog)
+	}
+
+	if err := NewFile().Init(`{"filename":"app.log","files":{"fatal":"x.log"}}`); err == nil {
+		t.Error("accepted an unknown level")
+	}
+}
+
+func TestFileHeader(t *testing.T) {
+	inTempDir(t)
+	const header = "#logformat=text v1 fields=time,level,msg"
+	cfg := `{"filename":"app.log","append":true,"header":"` + header + `\n"}`
+
+	runFileLogger(t, cfg, "first run")
+	runFileLogger(t, cfg, "second run")
+	got := readFile(t, "app.log")
+	if !strings.HasPrefix(got, header+"\n") || strings.Count(got, header) != 1 {
+		t.Errorf("append mode got %q", got)
+	}
+	if !strings.Contains(got, "first run") || !strings.Contains(got, "second run") {
+		t.Errorf("got %q", got)
+	}
+
+	// 清空后重新写 header
+	runFileLogger(t, `{"filename":"app.log","append":false,"header":"`+header+`"}`, "truncated")
+	if got := readFile(t, "app.log"); !strings.HasPrefix(got, header+"\n") || strings.Count(got, header) != 1 || strings.Contains(got, "first run") {
+		t.Errorf("truncating mode got %q", got)
+	}
+}
+
+func TestFileQualifiedNames(t *testing.T) {
+	inTempDir(t)
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	al.SetMaxAdapters(2)
+	if err := al.AddLogger("file#errors", `{"filename":"errors.log","level":0,"color":false}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := al.AddLogger("file#app", `{"filename":"app.log","color":false}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := al.AddLogger("file#app", `{"filename":"other.log"}`); err == nil {
+		t.Error("added a duplicate name")
+	}
+	if err := al.AddWriter("third", ioutil.Discard, LevelDebug); err == nil {
+		t.Error("added more than the maximum")
+	}
+	al.SetMaxAdapters(0)
+	if err := al.AddLogger("nosuch#x"); err == nil {
+		t.Error("added an unregistered adapter type")
+	}
+	al.Error("disk full")
+	al.Info("request handled")
+	al.Close()
+
+	if got := readFile(t, "errors.log"); !strings.Contains(got, "disk full") || strings.Contains(got, "request handled") {
+		t.Errorf("errors.log got %q", got)
+	}
+	if got := readFile(t, "app.log"); !strings.Contains(got, "disk full") || !strings.Contains(got, "request handled") {
+		t.Errorf("app.log got %q", got)
+	}
+}
+
+func TestFileSessionSeparator(t *testing.T) {
+	inTempDir(t)
+	if err := ioutil.WriteFile("app.log", []byte("old run\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runFileLogger(t, `{"filename":"app.log","session_separator":true}`, "new run")
+	lines := strings.Split(readFile(t, "app.log"), "\n")
+	if len(lines) != 4 || lines[0] != "old run" || !strings.HasPrefix(lines[1], "--- new session ") || !strings.HasSuffix(lines[1], " ---") || !strings.HasSuffix(lines[2], "new run") {
+		t.Errorf("got %q", lines)
+	}
+
+	// 空文件和没有开启时不写分隔行
+	os.Remove("app.log")
+	runFileLogger(t, `{"filename":"app.log","session_separator":true}`, "first")
+	runFileLogger(t, `{"filename":"app.log"}`, "second")
+	if got := readFile(t, "app.log"); strings.Contains(got, "new session") {
+		t.Errorf("got %q", got)
+	}
+}
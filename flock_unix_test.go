@@ -0,0 +1,50 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package logs
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 多个各自打开同一个文件的 file Logger 并发写很长的行，每一行都完整
+func TestFileLockLines(t *testing.T) {
+	inTempDir(t)
+	const writers, lines = 4, 50
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		lg := NewFile()
+		if err := lg.Init(`{"filename":"shared.log","append":true,"flock":true,"color":false}`); err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func(w int, lg Logger) {
+			defer wg.Done()
+			defer lg.Destroy()
+			payload := strings.Repeat(string(rune('a'+w)), 256<<10)
+			for i := 0; i < lines; i++ {
+				lg.WriteMsg(time.Now(), fmt.Sprintf("[I]  w%d %s", w, payload), LevelInfo)
+			}
+		}(w, lg)
+	}
+	wg.Wait()
+
+	got := strings.Split(strings.TrimSuffix(readFile(t, "shared.log"), "\n"), "\n")
+	if len(got) != writers*lines {
+		t.Fatalf("%d lines, want %d", len(got), writers*lines)
+	}
+	for i, line := range got {
+		var w int
+		if _, err := fmt.Sscanf(line[strings.Index(line, "w"):], "w%d", &w); err != nil {
+			t.Fatalf("line %d: %v", i, err)
+		}
+		want := fmt.Sprintf("[I]  w%d %s", w, strings.Repeat(string(rune('a'+w)), 256<<10))
+		if !strings.HasSuffix(line, want) || len(line) != len("2006-01-02 15:04:05.000  ")+len(want) {
+			t.Fatalf("line %d interleaved", i)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package logs
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFlushAdapter(t *testing.T) {
+	al, c := newTestLogger(t)
+	other := &captureLogger{}
+	al.AddAdapter("other", other)
+	al.Async()
+	defer al.Close()
+
+	for i := 0; i < 100; i++ {
+		al.Info("msg %d", i)
+	}
+	if err := al.FlushAdapter("capture"); err != nil {
+		t.Fatal(err)
+	}
+	// 排队的log都已经写出，只有 capture 被刷新
+	if n := len(c.all()); n != 100 {
+		t.Errorf("%d messages written after FlushAdapter, want 100", n)
+	}
+	if c.flushed != 1 || other.flushed != 0 {
+		t.Errorf("flushed capture %d times, other %d times", c.flushed, other.flushed)
+	}
+	if err := al.FlushAdapter("nope"); err == nil {
+		t.Error("no error for an unknown adapter")
+	}
+}
+
+func TestFlushAdapterAfterClose(t *testing.T) {
+	al, _ := newTestLogger(t)
+	al.Async()
+	al.Close()
+	if err := al.FlushAdapter("capture"); err == nil {
+		t.Error("no error after Close")
+	}
+	// 不能向已经关闭的 signalChan 发送
+	al.Flush()
+}
+
+// 多个 goroutine 同时 Flush 和 FlushAdapter 时每个都要等到自己的信号处理完
+func TestConcurrentFlush(t *testing.T) {
+	al, c := newTestLogger(t)
+	al.Async(16)
+	const goroutines, rounds = 8, 50
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				al.Info("g%d %d", g, i)
+				switch i % 3 {
+				case 0:
+					al.Flush()
+				case 1:
+					al.FlushAdapter("capture")
+				default:
+					al.ResizeAsyncBuffer(int64(16 + i%2))
+				}
+			}
+		}(g)
+	}
+	within(t, 10*time.Second, "concurrent flushes", wg.Wait)
+	within(t, 5*time.Second, "Close", al.Close)
+	if n := len(c.all()); n != goroutines*rounds {
+		t.Errorf("%d messages written, want %d", n, goroutines*rounds)
+	}
+}
+
+// orderLogger 把 Flush 和 Destroy 的调用记到 calls
+type orderLogger struct {
+	captureLogger
+	name  string
+	mu    *sync.Mutex
+	calls *[]string
+}
+
+func (o *orderLogger) record(call string) {
+	o.mu.Lock()
+	*o.calls = append(*o.calls, call+" "+o.name)
+	o.mu.Unlock()
+}
+
+func (o *orderLogger) Flush()   { o.record("flush") }
+func (o *orderLogger) Destroy() { o.record("destroy") }
+
+func TestCloseOrder(t *testing.T) {
+	for _, tc := range []struct {
+		order []string
+		want  string
+	}{
+		{nil, "flush a|flush b|flush c|destroy c|destroy b|destroy a"},
+		{[]string{"c", "missing", "a"}, "flush c|flush a|flush b|destroy b|destroy a|destroy c"},
+	} {
+		al := NewAppLogger()
+		al.RemoveLogger(AdapterConsole)
+		var mu sync.Mutex
+		var calls []string
+		for _, name := range []string{"a", "b", "c"} {
+			al.AddAdapter(name, &orderLogger{name: name, mu: &mu, calls: &calls})
+		}
+		al.SetCloseOrder(tc.order...)
+		al.Async()
+		al.Info("x")
+		al.Close()
+		if got := strings.Join(calls, "|"); got != tc.want {
+			t.Errorf("order %q: got %q, want %q", tc.order, got, tc.want)
+		}
+	}
+}
+
+// 其他 goroutine 一直在写log时 Flush 也能返回，并且写出了调用 Flush 之前的log
+func TestFlushUnderLoad(t *testing.T) {
+	al, c := newTestLogger(t)
+	al.Async(100)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					al.Info("background")
+				}
+			}
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		al.Info("before flush %d", i)
+	}
+	within(t, 2*time.Second, "Flush", al.Flush)
+
+	before := 0
+	for _, l := range c.lines() {
+		if strings.HasPrefix(l, "[I]  before flush ") {
+			before++
+		}
+	}
+	close(stop)
+	wg.Wait()
+	al.Close()
+	if before != 50 {
+		t.Errorf("%d of 50 earlier messages written when Flush returned", before)
+	}
+}
@@ -0,0 +1,168 @@
+package logs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// formatMsg 按 format 格式化 v；format 里没有有效的格式化动词时不调用 fmt.Sprintf，
+// 而是把 v 用 fmt.Sprint 追加在后面，避免 "50% done" 这类消息出现 %!d(MISSING) 之类的乱码
+func formatMsg(format string, v []interface{}) string {
+	if len(v) == 0 {
+		return format
+	}
+	if format == "" {
+		return fmt.Sprint(v...)
+	}
+	if !hasFormatVerb(format) {
+		return format + " " + fmt.Sprint(v...)
+	}
+	return fmt.Sprintf(format, v...)
+}
+
+// hasFormatVerb 判断 format 里是否有格式化动词，"%%" 和 '%' 后面跟空格都当作普通字符
+func hasFormatVerb(format string) bool {
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		// flags
+		for i < len(format) && (format[i] == '+' || format[i] == '-' || format[i] == '#' || format[i] == '0') {
+			i++
+		}
+		// width and precision
+		for i < len(format) && (format[i] >= '0' && format[i] <= '9' || format[i] == '.' || format[i] == '*') {
+			i++
+		}
+		// argument index, e.g. %[1]d
+		if i < len(format) && format[i] == '[' {
+			for i < len(format) && format[i] != ']' {
+				i++
+			}
+			i++
+		}
+		if i < len(format) && isVerb(format[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func isVerb(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// SetFormatGuard 开启后，第一次遇到有格式化动词却没有参数的格式（如 al.Info(userInput) 或 al.Info(fmt.Sprintf(...))）时
+// 写一条 Warning，提醒把不可信的内容作为参数传入；默认关闭
+func (al *AppLogger) SetFormatGuard(b bool) {
+	if b {
+		atomic.CompareAndSwapInt32(&al.formatGuard, 0, 1)
+	} else {
+		atomic.StoreInt32(&al.formatGuard, 0)
+	}
+}
+
+const formatGuardWarning = "logs: format string has verbs but no arguments, pass untrusted text as an argument instead"
+
+// suspiciousFormat 开启了 SetFormatGuard 并且还没有警告过时，判断 format 是否有格式化动词却没有参数
+func (al *AppLogger) suspiciousFormat(format string, v []interface{}) bool {
+	if len(v) > 0 || atomic.LoadInt32(&al.formatGuard) != 1 || !hasFormatVerb(format) {
+		return false
+	}
+	return atomic.CompareAndSwapInt32(&al.formatGuard, 1, 2)
+}
+
+// guardFormat 在 format 可疑时写一条 formatGuardWarning，由 Info 这一类函数直接调用，调用位置指向用户代码
+func (al *AppLogger) guardFormat(format string, v []interface{}) {
+	if al.suspiciousFormat(format, v) {
+		al.output(1, true, LevelWarning, []Field{String("format", format)}, formatGuardWarning, nil)
+	}
+}
+
+// Fields 命名占位符的值，用于 InfoT 等
+type Fields map[string]interface{}
+
+// formatTemplate 把 tmpl 里的 {name} 替换为 fields[name]，没有对应值的写成 {name:?}；
+// 返回替换后的消息和没有用到的字段，字段按 key 排序
+func formatTemplate(tmpl string, fields Fields) (string, []Field) {
+	used := make(map[string]bool)
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(tmpl, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(tmpl[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+		name := tmpl[start+1 : end]
+		if name == "" || strings.ContainsAny(name, "{ ") {
+			b.WriteString(tmpl[:start+1])
+			tmpl = tmpl[start+1:]
+			continue
+		}
+		b.WriteString(tmpl[:start])
+		if v, ok := fields[name]; ok {
+			b.WriteString(fmt.Sprint(v))
+			used[name] = true
+		} else {
+			b.WriteString("{" + name + ":?}")
+		}
+		tmpl = tmpl[end+1:]
+	}
+	b.WriteString(tmpl)
+
+	var keys []string
+	for k := range fields {
+		if !used[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	extra := make([]Field, len(keys))
+	for i, k := range keys {
+		extra[i] = Field{Key: k, Value: fields[k]}
+	}
+	return b.String(), extra
+}
+
+// InfoT 以 Info 级别写log，tmpl 里的 {name} 用 fields 中的值替换，没有用到的字段追加在后面
+func (al *AppLogger) InfoT(tmpl string, fields Fields) {
+	if !al.Enabled(LevelInfo) {
+		return
+	}
+	msg, extra := formatTemplate(tmpl, fields)
+	al.writeMsg(LevelInfo, extra, msg)
+}
+
+// WarnT 同 InfoT，Warning 级别
+func (al *AppLogger) WarnT(tmpl string, fields Fields) {
+	if !al.Enabled(LevelWarning) {
+		return
+	}
+	msg, extra := formatTemplate(tmpl, fields)
+	al.writeMsg(LevelWarning, extra, msg)
+}
+
+// DebugT 同 InfoT，Debug 级别
+func (al *AppLogger) DebugT(tmpl string, fields Fields) {
+	if !al.Enabled(LevelDebug) {
+		return
+	}
+	msg, extra := formatTemplate(tmpl, fields)
+	al.writeMsg(LevelDebug, extra, msg)
+}
+
+// ErrorT 同 InfoT，Error 级别
+func (al *AppLogger) ErrorT(tmpl string, fields Fields) {
+	if !al.Enabled(LevelError) {
+		return
+	}
+	msg, extra := formatTemplate(tmpl, fields)
+	al.writeMsg(LevelError, extra, msg)
+}
@@ -0,0 +1,101 @@
+package logs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatGuard(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.EnableFuncCallDepth(true)
+
+	al.Info("100%s done")
+	if n := len(c.all()); n != 1 {
+		t.Fatalf("guard off: got %d records", n)
+	}
+
+	al.SetFormatGuard(true)
+	al.Info("plain")
+	al.Info("%d items", 3)
+	al.Child().Warn("50%d off")
+	al.Info("%s again")
+	al.InfoSampled(1, "%s sampled")
+
+	records := c.all()[1:]
+	var warnings []Record
+	for _, r := range records {
+		if r.Msg == formatGuardWarning {
+			warnings = append(warnings, r)
+		}
+	}
+	if len(warnings) != 1 {
+		t.Fatalf("got %d warnings in %d records", len(warnings), len(records))
+	}
+	w := warnings[0]
+	if w.Level != LevelWarning || w.Fields["format"] != "50%d off" {
+		t.Errorf("warning %+v", w)
+	}
+	if w.File != "format_test.go" {
+		t.Errorf("warning reported from %s:%d", w.File, w.Line)
+	}
+	if n := len(records); n != 6 {
+		t.Errorf("got %d records, want 5 messages and 1 warning", n)
+	}
+}
+
+func TestFormatGuardEntry(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.SetFormatGuard(true)
+	al.WithFields(String("k", "v")).Error("%v")
+	records := c.all()
+	if len(records) != 2 || records[0].Msg != formatGuardWarning || records[1].Fields["k"] != "v" {
+		t.Errorf("got %q", c.lines())
+	}
+}
+
+func TestFormatMsg(t *testing.T) {
+	cases := []struct {
+		format string
+		v      []interface{}
+		want   string
+	}{
+		{"50% done", []interface{}{3}, "50% done 3"},
+		{"100%", []interface{}{1, 2}, "100% 1 2"},
+		{"100%% sure", []interface{}{true}, "100%% sure true"},
+		{"%d items in %s", []interface{}{3, "cart"}, "3 items in cart"},
+		{"%[1]s-%[1]s", []interface{}{"a"}, "a-a"},
+		{"", []interface{}{"a", 1}, "a1"},
+		{"no args %d", nil, "no args %d"},
+	}
+	for _, c := range cases {
+		if got := formatMsg(c.format, c.v); got != c.want {
+			t.Errorf("formatMsg(%q, %v) = %q, want %q", c.format, c.v, got, c.want)
+		}
+	}
+}
+
+func TestInfoT(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.InfoT("user {user} bought {count} items", Fields{"user": "alice", "count": 3, "order": "A1", "cart": 9})
+	al.WarnT("missing {who}, literal { brace } and {}", nil)
+	al.SetLevel(LevelInfo)
+	al.DebugT("hidden {x}", Fields{"x": 1})
+	al.ErrorT("{a}{b}", Fields{"a": 1, "b": 2})
+
+	records := c.all()
+	if len(records) != 3 {
+		t.Fatalf("got %q", c.lines())
+	}
+	if r := records[0]; r.Msg != "user alice bought 3 items" || !strings.HasSuffix(r.String(), "items cart=9 order=A1") {
+		t.Errorf("got %q", r.String())
+	}
+	if r := records[1]; r.Level != LevelWarning || r.Msg != "missing {who:?}, literal { brace } and {}" {
+		t.Errorf("got %q at level %d", r.Msg, r.Level)
+	}
+	if r := records[2]; r.Level != LevelError || r.Msg != "12" {
+		t.Errorf("got %q at level %d", r.Msg, r.Level)
+	}
+}
@@ -0,0 +1,215 @@
+package logs
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Formatter 在 AppLogger 这一层统一决定每行log的格式，设置之后 Logger 只负责把 Format 返回的字节写出。
+// level 为 -1 时表示不带级别的log（如通过 Write 写入的内容）
+type Formatter interface {
+	Format(when time.Time, level int, msg string, fields map[string]interface{}) []byte
+}
+
+// RawWriter 可以直接写出 Formatter 结果的 Logger，没有实现的 Logger 仍然通过 WriteMsg 写出
+type RawWriter interface {
+	WriteRaw(p []byte, level int) error
+}
+
+// TextFormatter 默认的文本格式：时间头、级别、消息，字段按 key 排序以 "k=v" 追加在后面
+type TextFormatter struct {
+	TimeFormat string // 为空时使用默认格式
+}
+
+// Format implementing Formatter.
+func (t TextFormatter) Format(when time.Time, level int, msg string, fields map[string]interface{}) []byte {
+	timeFormat := t.TimeFormat
+	if timeFormat == "" {
+		timeFormat = layout
+	}
+	var b strings.Builder
+	b.WriteString(when.Format(timeFormat))
+	b.WriteString("  ")
+	if validLevel(level) {
+		b.WriteString(levelPrefix[level])
+		b.WriteByte(' ')
+	}
+	b.WriteString(msg)
+	if len(fields) > 0 {
+		b.WriteByte(' ')
+		b.WriteString(formatFields(sortedFields(fields)))
+	}
+	b.WriteByte('\n')
+	return []byte(b.String())
+}
+
+// JSONFormatter 每行一个 JSON 对象，包括 time、level、msg 和所有字段
+type JSONFormatter struct {
+	EscapeHTML    bool // 是否把 <>& 转义为 \u003c 这种形式
+	SeverityScale int  // 不为 SeverityScaleNone 时加上数字的 severity 字段；和 AppLogger.SetSeverityScale 互不影响，需要单独设置
+}
+
+// Format implementing Formatter.
+func (j JSONFormatter) Format(when time.Time, level int, msg string, fields map[string]interface{}) []byte {
+	m := make(map[string]interface{}, len(fields)+3)
+	for k, v := range fields {
+		m[k] = v
+	}
+	m["time"] = when.Format(time.RFC3339Nano)
+	if validLevel(level) {
+		m["level"] = levelTokens[LevelStyleLong][level]
+		if n, ok := severity(j.SeverityScale, level); ok {
+			m["severity"] = n
+		}
+	}
+	m["msg"] = msg
+	line, err := encodeJSON(m, j.EscapeHTML)
+	if err != nil {
+		line, _ = encodeJSON(map[string]interface{}{"time": m["time"], "msg": msg, "error": err.Error()}, j.EscapeHTML)
+	}
+	return line
+}
+
+// LogfmtFormatter 每行一组 logfmt 格式的 key=value，如 Loki、Heroku 使用的格式：time、level、msg 在前，字段按 key 排序；
+// 值为空或者含有空格、'='、'"'、控制字符时加上双引号并转义。字段和 time、level、msg 重名时以后者为准
+type LogfmtFormatter struct {
+	TimeFormat string // 为空时使用 time.RFC3339Nano
+}
+
+// Format implementing Formatter.
+func (l LogfmtFormatter) Format(when time.Time, level int, msg string, fields map[string]interface{}) []byte {
+	timeFormat := l.TimeFormat
+	if timeFormat == "" {
+		timeFormat = time.RFC3339Nano
+	}
+	var b strings.Builder
+	writeLogfmtPair(&b, "time", when.Format(timeFormat))
+	if validLevel(level) {
+		writeLogfmtPair(&b, "level", strings.ToLower(levelTokens[LevelStyleLong][level]))
+	}
+	writeLogfmtPair(&b, "msg", msg)
+	for _, f := range sortedFields(fields) {
+		if f.Key == "time" || f.Key == "level" || f.Key == "msg" {
+			continue
+		}
+		value := "null"
+		if f.Value != nil {
+			value = fmt.Sprint(f.Value)
+		}
+		writeLogfmtPair(&b, logfmtKey(f.Key), value)
+	}
+	b.WriteByte('\n')
+	return []byte(b.String())
+}
+
+// writeLogfmtPair 写出一组 key=value，不是第一组时前面加空格
+func writeLogfmtPair(b *strings.Builder, key, value string) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	b.WriteString(key)
+	b.WriteByte('=')
+	if !logfmtNeedsQuote(value) {
+		b.WriteString(value)
+		return
+	}
+	b.WriteByte('"')
+	for _, c := range value {
+		switch c {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if c < ' ' || c == 0x7f {
+				fmt.Fprintf(b, `\u%04x`, c)
+				continue
+			}
+			b.WriteRune(c)
+		}
+	}
+	b.WriteByte('"')
+}
+
+// logfmtNeedsQuote 返回 value 是否需要加引号
+func logfmtNeedsQuote(value string) bool {
+	if value == "" {
+		return true
+	}
+	for _, c := range value {
+		if c <= ' ' || c == '=' || c == '"' || c == 0x7f || c == utf8.RuneError {
+			return true
+		}
+	}
+	return false
+}
+
+// logfmtKey 把 key 中不能出现在 logfmt key 里的字符换成 '_'
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(c rune) rune {
+		if c <= ' ' || c == '=' || c == '"' || c == 0x7f {
+			return '_'
+		}
+		return c
+	}, key)
+}
+
+// sortedFields 把 fields 按 key 排序
+func sortedFields(fields map[string]interface{}) []Field {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	sorted := make([]Field, len(keys))
+	for i, k := range keys {
+		sorted[i] = Field{Key: k, Value: fields[k]}
+	}
+	return sorted
+}
+
+// SetFormatter 设置所有 Logger 使用的 Formatter，f 为 nil 时恢复各个 Logger 自己的格式
+func (al *AppLogger) SetFormatter(f Formatter) {
+	al.formatter.Store(formatterHolder{f})
+}
+
+type formatterHolder struct {
+	Formatter
+}
+
+func (al *AppLogger) getFormatter() Formatter {
+	h, _ := al.formatter.Load().(formatterHolder)
+	return h.Formatter
+}
+
+// formatterMsg 交给 Formatter 的消息：调用位置、前缀和消息，不含级别和字段
+func (r *Record) formatterMsg() string {
+	parts := make([]string, 0, 4)
+	if r.Seq != 0 {
+		parts = append(parts, seqToken(r.Seq))
+	}
+	if r.File != "" {
+		caller := r.File + ":" + strconv.Itoa(r.Line)
+		if r.Func != "" {
+			caller += " " + r.Func
+		}
+		parts = append(parts, "["+caller+"]")
+	}
+	if p := strings.TrimSpace(r.Prefix); p != "" {
+		parts = append(parts, p)
+	}
+	parts = append(parts, r.Msg)
+	return strings.Join(parts, " ")
+}
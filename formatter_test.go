@@ -0,0 +1,202 @@
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// plainFormatter 只输出级别和消息，方便检查 Logger 有没有再加时间头
+type plainFormatter struct{}
+
+func (plainFormatter) Format(when time.Time, level int, msg string, fields map[string]interface{}) []byte {
+	return []byte(fmt.Sprintf("FMT|%d|%s\n", level, msg))
+}
+
+func TestFormatterTee(t *testing.T) {
+	inTempDir(t)
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	err := al.AddLogger(AdapterTee, `{"first":{"adapter":"file","level":3,"config":{"filename":"all.log"}},`+
+		`"second":{"adapter":"file","level":0,"config":{"filename":"errors.log"}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	al.SetFormatter(plainFormatter{})
+	al.Info("hello")
+	al.Error("boom")
+	al.Close()
+
+	for name, want := range map[string]string{
+		"all.log":    "FMT|2|hello\nFMT|0|boom\n",
+		"errors.log": "FMT|0|boom\n",
+	} {
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("%s = %q, want %q", name, b, want)
+		}
+	}
+}
+
+func TestFormatterUnixSocket(t *testing.T) {
+	for _, framing := range []string{FramingNewline, FramingLength} {
+		path, ln := listenUnix(t)
+		al := NewAppLogger()
+		al.RemoveLogger(AdapterConsole)
+		if err := al.AddLogger(AdapterUnixSocket, `{"path":"`+path+`","framing":"`+framing+`"}`); err != nil {
+			t.Fatal(err)
+		}
+		conn, err := ln.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+		al.SetFormatter(plainFormatter{})
+		al.Info("hello")
+		al.Close()
+
+		b, err := ioutil.ReadAll(bufio.NewReader(conn))
+		conn.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "FMT|2|hello\n"
+		if framing == FramingLength {
+			want = "\x00\x00\x00\x0bFMT|2|hello"
+		}
+		if string(b) != want {
+			t.Errorf("%s framing: got %q, want %q", framing, b, want)
+		}
+	}
+}
+
+func TestFormatterWriteMsgFallback(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.SetFormatter(plainFormatter{})
+	al.Warn("hi %d", 1)
+	lines := c.lines()
+	// 没有实现 RawWriter 的 Logger 通过 WriteMsg 收到去掉结尾换行的 Formatter 结果
+	if len(lines) != 1 || lines[0] != "FMT|1|hi 1" {
+		t.Errorf("got %q", lines)
+	}
+}
+
+// parseLogfmt 解析一行 logfmt，值可以带引号和 \" \\ \n \r \t \uXXXX 转义
+func parseLogfmt(t *testing.T, line string) map[string]string {
+	t.Helper()
+	pairs := make(map[string]string)
+	for i := 0; i < len(line); {
+		eq := strings.IndexByte(line[i:], '=')
+		if eq < 0 {
+			t.Fatalf("no '=' after %q", line[i:])
+		}
+		key := line[i : i+eq]
+		i += eq + 1
+		var value string
+		if i < len(line) && line[i] == '"' {
+			var b strings.Builder
+			for i++; ; i++ {
+				if i >= len(line) {
+					t.Fatalf("unterminated value in %q", line)
+				}
+				c := line[i]
+				if c == '"' {
+					i++
+					break
+				}
+				if c != '\\' {
+					b.WriteByte(c)
+					continue
+				}
+				i++
+				switch line[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 'r':
+					b.WriteByte('\r')
+				case 't':
+					b.WriteByte('\t')
+				case 'u':
+					r, err := strconv.ParseUint(line[i+1:i+5], 16, 32)
+					if err != nil {
+						t.Fatal(err)
+					}
+					b.WriteRune(rune(r))
+					i += 4
+				default:
+					b.WriteByte(line[i])
+				}
+			}
+			value = b.String()
+		} else {
+			end := strings.IndexByte(line[i:], ' ')
+			if end < 0 {
+				end = len(line) - i
+			}
+			value = line[i : i+end]
+			i += end
+		}
+		if _, dup := pairs[key]; dup {
+			t.Errorf("duplicate key %q in %q", key, line)
+		}
+		pairs[key] = value
+		if i < len(line) {
+			if line[i] != ' ' {
+				t.Fatalf("no space after %q=%q in %q", key, value, line)
+			}
+			i++
+		}
+	}
+	return pairs
+}
+
+func TestLogfmtFormatter(t *testing.T) {
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	defer al.Close()
+	var buf bytes.Buffer
+	al.AddWriter("buf", &buf, LevelDebug)
+	al.SetFormatter(LogfmtFormatter{TimeFormat: time.RFC3339})
+	al.SetTimeFunc(func(time.Time) time.Time { return time.Date(2024, 3, 9, 14, 5, 6, 0, time.UTC) })
+
+	tricky := []string{
+		"plain",
+		"with space",
+		"a=b",
+		`say "hi"`,
+		`back\slash`,
+		"line\nbreak\ttab\rreturn",
+		"bell\a del\x7f",
+		"",
+		"日本語",
+	}
+	for _, v := range tricky {
+		buf.Reset()
+		al.Log(LevelWarning, v, String("value", v), String("msg", "shadowed"), Int("n", 1), Field{Key: "nil"})
+		line := buf.String()
+		if strings.Count(line, "\n") != 1 || !strings.HasSuffix(line, "\n") {
+			t.Fatalf("not a single line: %q", line)
+		}
+		got := parseLogfmt(t, strings.TrimSuffix(line, "\n"))
+		want := map[string]string{"time": "2024-03-09T14:05:06Z", "level": "warn", "msg": v, "value": v, "n": "1", "nil": "null"}
+		if len(got) != len(want) {
+			t.Errorf("%q: got %q", v, got)
+		}
+		for k, w := range want {
+			if got[k] != w {
+				t.Errorf("%q: %s=%q, want %q in %q", v, k, got[k], w, line)
+			}
+		}
+		if !strings.HasPrefix(line, "time=2024-03-09T14:05:06Z level=warn msg=") {
+			t.Errorf("time, level and msg not first: %q", line)
+		}
+	}
+}
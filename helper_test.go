@@ -0,0 +1,318 @@
+package logs
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// captureLogger 保存写到它的所有log，测试用；err 不为 nil 时每次写入都返回它
+type captureLogger struct {
+	mu        sync.Mutex
+	records   []Record
+	err       error
+	flushed   int
+	destroyed int
+}
+
+func (c *captureLogger) Init(jsonConfig string) error {
+	return nil
+}
+
+func (c *captureLogger) WriteMsg(when time.Time, msg string, level int) error {
+	return c.WriteRecord(&Record{When: when, Level: level, Msg: msg, text: msg})
+}
+
+func (c *captureLogger) WriteRecord(r *Record) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	rec := *r
+	if r.Fields != nil {
+		rec.Fields = make(map[string]interface{}, len(r.Fields))
+		for k, v := range r.Fields {
+			rec.Fields[k] = v
+		}
+	}
+	c.records = append(c.records, rec)
+	return nil
+}
+
+func (c *captureLogger) Destroy() {
+	c.mu.Lock()
+	c.destroyed++
+	c.mu.Unlock()
+}
+
+func (c *captureLogger) Flush() {
+	c.mu.Lock()
+	c.flushed++
+	c.mu.Unlock()
+}
+
+func (c *captureLogger) setErr(err error) {
+	c.mu.Lock()
+	c.err = err
+	c.mu.Unlock()
+}
+
+// reset 丢掉已经写出的log
+func (c *captureLogger) reset() {
+	c.mu.Lock()
+	c.records = nil
+	c.mu.Unlock()
+}
+
+// all 返回到目前为止写出的所有log
+func (c *captureLogger) all() []Record {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Record(nil), c.records...)
+}
+
+// lines 返回到目前为止写出的所有log的整行内容
+func (c *captureLogger) lines() []string {
+	records := c.all()
+	lines := make([]string, len(records))
+	for i := range records {
+		lines[i] = records[i].text
+	}
+	return lines
+}
+
+// newTestLogger 返回只写到 captureLogger 的 AppLogger，所有级别都打开
+func newTestLogger(t testing.TB) (*AppLogger, *captureLogger) {
+	t.Helper()
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	c := &captureLogger{}
+	if err := al.AddAdapter("capture", c); err != nil {
+		t.Fatal(err)
+	}
+	return al, c
+}
+
+// inTempDir 在临时目录里执行测试，避免 NewFile 等在当前目录留下 default.log
+func inTempDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+// fakeExit 把 exitFunc 换成记录退出码的函数，测试结束时恢复
+func fakeExit(t *testing.T) *[]int {
+	var codes []int
+	old := exitFunc
+	exitFunc = func(code int) { codes = append(codes, code) }
+	t.Cleanup(func() { exitFunc = old })
+	return &codes
+}
+
+// contains 返回 lines 中是否有一行包含 substr
+func contains(lines []string, substr string) bool {
+	for _, l := range lines {
+		if strings.Contains(l, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// within 在 d 内执行完 f，否则报告测试失败，用于检查死锁
+func within(t *testing.T, d time.Duration, name string, f func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		f()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(d):
+		t.Fatalf("%s did not return within %v", name, d)
+	}
+}
+
+// fakeDB 测试用的数据库驱动 "logsfake" 中一个 dsn 对应的数据，err 不为 nil 时 Exec 返回它
+type fakeDB struct {
+	mu   sync.Mutex
+	rows [][]driver.Value
+	err  error
+}
+
+var (
+	fakeDBsLock sync.Mutex
+	fakeDBs     = map[string]*fakeDB{}
+)
+
+func init() {
+	sql.Register("logsfake", fakeDriver{})
+}
+
+// openFakeDB 返回 dsn 对应的 *sql.DB 和它的数据
+func openFakeDB(t testing.TB, dsn string) (*sql.DB, *fakeDB) {
+	t.Helper()
+	fakeDBsLock.Lock()
+	data := &fakeDB{}
+	fakeDBs[dsn] = data
+	fakeDBsLock.Unlock()
+	db, err := sql.Open("logsfake", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, data
+}
+
+func (d *fakeDB) setErr(err error) {
+	d.mu.Lock()
+	d.err = err
+	d.mu.Unlock()
+}
+
+func (d *fakeDB) messages() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	msgs := make([]string, len(d.rows))
+	for i, row := range d.rows {
+		msgs[i], _ = row[2].(string)
+	}
+	return msgs
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(dsn string) (driver.Conn, error) {
+	fakeDBsLock.Lock()
+	defer fakeDBsLock.Unlock()
+	data, ok := fakeDBs[dsn]
+	if !ok {
+		return nil, errors.New("fake db: unknown dsn " + dsn)
+	}
+	return &fakeConn{data: data}, nil
+}
+
+// fakeConn 事务提交时才把 rows 写到 data
+type fakeConn struct {
+	data    *fakeDB
+	pending [][]driver.Value
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{c}, nil }
+func (c *fakeConn) Close() error                              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                 { c.pending = nil; return c, nil }
+
+func (c *fakeConn) Commit() error {
+	c.data.mu.Lock()
+	c.data.rows = append(c.data.rows, c.pending...)
+	c.data.mu.Unlock()
+	c.pending = nil
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.pending = nil
+	return nil
+}
+
+type fakeStmt struct{ c *fakeConn }
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return 3 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.c.data.mu.Lock()
+	err := s.c.data.err
+	s.c.data.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	s.c.pending = append(s.c.pending, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("fake db: query not supported")
+}
+
+// fakeCloudWatch 测试用的 CloudWatchClient，err 不为 nil 时 PutLogEvents 返回它
+type fakeCloudWatch struct {
+	mu     sync.Mutex
+	events []CloudWatchEvent
+	err    error
+}
+
+func (f *fakeCloudWatch) PutLogEvents(group, stream string, events []CloudWatchEvent, sequenceToken *string) (*string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.events = append(f.events, events...)
+	return sequenceToken, nil
+}
+
+func (f *fakeCloudWatch) setErr(err error) {
+	f.mu.Lock()
+	f.err = err
+	f.mu.Unlock()
+}
+
+// fakeStackdriver 测试用的 StackdriverClient，err 不为 nil 时 WriteEntries 返回它
+type fakeStackdriver struct {
+	mu      sync.Mutex
+	entries []StackdriverEntry
+	err     error
+}
+
+func (f *fakeStackdriver) WriteEntries(logName string, entries []StackdriverEntry) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.entries = append(f.entries, entries...)
+	return nil
+}
+
+func (f *fakeStackdriver) setErr(err error) {
+	f.mu.Lock()
+	f.err = err
+	f.mu.Unlock()
+}
+
+// listenUnix 在一个短路径的临时目录里监听 Unix domain socket，返回 socket 的路径；
+// t.TempDir 的路径可能超过 sun_path 的长度限制
+func listenUnix(t testing.TB) (string, net.Listener) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "s")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	return path, ln
+}
@@ -0,0 +1,79 @@
+package logs
+
+import (
+	"sync"
+	"time"
+)
+
+// levelHooks 中等待执行的回调数上限，满了之后新的回调被丢弃，不阻塞写log
+const levelHookQueueLen = 1024
+
+// OnLevel 注册的回调，在单独的 goroutine 里按顺序执行
+type levelHooks struct {
+	sync.Mutex
+	fns    [LevelDebug + 1][]func(when time.Time, msg string)
+	queue  chan levelHookCall
+	closed bool
+}
+
+type levelHookCall struct {
+	fn   func(when time.Time, msg string)
+	when time.Time
+	msg  string
+}
+
+// OnLevel 注册 level 级别的log写出之后执行的回调，如计数、报警；不带级别的log不会触发回调。
+// 回调在单独的 goroutine 里执行，不会阻塞写log，来不及执行的回调会被丢弃
+func (al *AppLogger) OnLevel(level int, fn func(when time.Time, msg string)) {
+	if !validLevel(level) || fn == nil {
+		return
+	}
+	h := &al.hooks
+	h.Lock()
+	defer h.Unlock()
+	if h.closed {
+		return
+	}
+	h.fns[level] = append(h.fns[level], fn)
+	if h.queue == nil {
+		h.queue = make(chan levelHookCall, levelHookQueueLen)
+		go h.run(h.queue)
+	}
+}
+
+// fire 把 r 级别的回调放进队列
+func (h *levelHooks) fire(r *Record) {
+	if r.noLevel || !validLevel(r.Level) {
+		return
+	}
+	h.Lock()
+	defer h.Unlock()
+	if h.closed {
+		return
+	}
+	for _, fn := range h.fns[r.Level] {
+		select {
+		case h.queue <- levelHookCall{fn, r.When, r.Msg}:
+		default:
+		}
+	}
+}
+
+func (h *levelHooks) run(queue chan levelHookCall) {
+	for c := range queue {
+		c.fn(c.when, c.msg)
+	}
+}
+
+// close 停止执行回调的 goroutine，已经在队列里的回调仍然会执行
+func (h *levelHooks) close() {
+	h.Lock()
+	defer h.Unlock()
+	if h.closed {
+		return
+	}
+	h.closed = true
+	if h.queue != nil {
+		close(h.queue)
+	}
+}
@@ -0,0 +1,51 @@
+package logs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOnLevel(t *testing.T) {
+	al, _ := newTestLogger(t)
+	defer al.Close()
+	alerts := make(chan string, 10)
+	al.OnLevel(LevelError, func(when time.Time, msg string) {
+		alerts <- msg
+	})
+	al.OnLevel(LevelDebug+1, func(time.Time, string) { t.Error("hook for an unknown level") })
+
+	al.Info("ignored")
+	al.Error("disk %s", "failed")
+	al.Write([]byte("untagged\n"))
+
+	select {
+	case msg := <-alerts:
+		if msg != "disk failed" {
+			t.Errorf("hook got %q", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("hook not called")
+	}
+	select {
+	case msg := <-alerts:
+		t.Errorf("unexpected hook call with %q", msg)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+// 回调很慢时不阻塞写log
+func TestOnLevelSlowHook(t *testing.T) {
+	al, c := newTestLogger(t)
+	release := make(chan struct{})
+	al.OnLevel(LevelError, func(time.Time, string) { <-release })
+	within(t, time.Second, "Error", func() {
+		for i := 0; i < 2*levelHookQueueLen; i++ {
+			al.Error("e")
+		}
+	})
+	close(release)
+	al.Close()
+	if n := len(c.all()); n != 2*levelHookQueueLen {
+		t.Errorf("%d records written", n)
+	}
+}
@@ -0,0 +1,106 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// JSON 输出中数字 severity 字段的取值方式，由 SetSeverityScale 选择
+const (
+	SeverityScaleNone   = iota // 不输出 severity 字段
+	SeverityScaleNative        // 级别本身：0 Error，1 Warning，2 Info，3 Debug
+	SeverityScaleSyslog        // RFC 5424 的 severity：3 Error，4 Warning，6 Informational，7 Debug
+)
+
+var syslogSeverities = [LevelDebug + 1]int{3, 4, 6, 7}
+
+// severity 返回 level 在 scale 下的数字，不输出时 ok 为 false
+func severity(scale int, level int) (n int, ok bool) {
+	if !validLevel(level) {
+		return 0, false
+	}
+	switch scale {
+	case SeverityScaleNative:
+		return level, true
+	case SeverityScaleSyslog:
+		return syslogSeverities[level], true
+	}
+	return 0, false
+}
+
+// SetSeverityScale 设置 JSON 输出中除了 level 名字之外再加上数字的 severity 字段，便于下游按数字过滤，
+// scale 为 SeverityScaleNative 或 SeverityScaleSyslog，SeverityScaleNone（缺省）时不输出
+func (al *AppLogger) SetSeverityScale(scale int) error {
+	if scale < SeverityScaleNone || scale > SeverityScaleSyslog {
+		return fmt.Errorf("logs: unknown severity scale %d", scale)
+	}
+	atomic.StoreInt32(&al.severityScale, int32(scale))
+	return nil
+}
+
+// jsonRecord 把 r 编码成一行 JSON，结尾带 '\n'。用 json.Encoder 编码，消息里的引号、反斜杠和控制字符都会正确转义；
+// escapeHTML 为 false 时 <>& 原样输出而不是 \u003c 这种形式
+func jsonRecord(r *Record, escapeHTML bool) ([]byte, error) {
+	m := make(map[string]interface{}, len(r.Fields)+6)
+	for k, v := range r.Fields {
+		m[k] = v
+	}
+	m["time"] = r.When.Format(time.RFC3339Nano)
+	if !r.noLevel {
+		m["level"] = levelToken(LevelStyleLong, r.Level)
+		if n, ok := severity(r.severityScale, r.Level); ok {
+			m["severity"] = n
+		}
+	}
+	m["msg"] = r.Msg
+	if r.Seq != 0 {
+		m["seq"] = r.Seq
+	}
+	if r.Prefix != "" {
+		m["prefix"] = r.Prefix
+	}
+	if r.File != "" {
+		m["file"] = r.File
+		m["line"] = r.Line
+	}
+	if r.Func != "" {
+		m["func"] = r.Func
+	}
+
+	return encodeJSON(m, escapeHTML)
+}
+
+// encodeJSON 用 json.Encoder 编码 m，结尾带 '\n'
+func encodeJSON(m map[string]interface{}, escapeHTML bool) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(escapeHTML)
+	if err := enc.Encode(m); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeJSON 把 r 以一行 JSON 写出，不带时间头
+func (lg *logWriter) writeJSON(r *Record, escapeHTML bool) (int, error) {
+	line, err := jsonRecord(r, escapeHTML)
+	if err != nil {
+		return 0, err
+	}
+	line = line[:len(line)-1]
+	lg.Lock()
+	n, err := lg.write(lg.frame(line))
+	lg.Unlock()
+	return n, err
+}
+
+// writeRaw 原样写出 p，由 Formatter 负责格式和分行
+func (lg *logWriter) writeRaw(p []byte) (int, error) {
+	lg.Lock()
+	n, err := lg.write(p)
+	lg.Unlock()
+	return n, err
+}
@@ -0,0 +1,96 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONEscaping(t *testing.T) {
+	var buf bytes.Buffer
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	defer al.Close()
+	raw := NewWriterAdapter(&buf, LevelDebug)
+	raw.Init(`{"json":true}`)
+	al.AddAdapter("raw", raw)
+	var escaped bytes.Buffer
+	html := NewWriterAdapter(&escaped, LevelDebug)
+	html.Init(`{"json":true,"escape_html":true}`)
+	al.AddAdapter("html", html)
+
+	msg := "quote \" backslash \\ newline \n tag <b>&amp;"
+	al.Info("%s", msg)
+
+	out := buf.String()
+	if strings.Count(out, "\n") != 1 {
+		t.Fatalf("not a single line: %q", out)
+	}
+	if !strings.Contains(out, `<b>&amp;`) {
+		t.Errorf("escaped HTML without escape_html: %s", out)
+	}
+	if !strings.Contains(escaped.String(), `\u003cb\u003e\u0026amp;`) {
+		t.Errorf("did not escape HTML: %s", escaped.String())
+	}
+	for _, line := range []string{out, escaped.String()} {
+		var got struct{ Msg, Level string }
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("%v: %s", err, line)
+		}
+		if got.Msg != msg || got.Level != "INFO" {
+			t.Errorf("decoded %+v", got)
+		}
+	}
+}
+
+func TestSetSeverityScale(t *testing.T) {
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	defer al.Close()
+	var buf bytes.Buffer
+	w := NewWriterAdapter(&buf, LevelDebug)
+	w.Init(`{"json":true}`)
+	al.AddAdapter("json", w)
+
+	levels := []struct {
+		level          int
+		name           string
+		native, syslog int
+	}{
+		{LevelError, "ERROR", 0, 3},
+		{LevelWarning, "WARN", 1, 4},
+		{LevelInfo, "INFO", 2, 6},
+		{LevelDebug, "DEBUG", 3, 7},
+	}
+	for _, scale := range []int{SeverityScaleNone, SeverityScaleNative, SeverityScaleSyslog} {
+		if err := al.SetSeverityScale(scale); err != nil {
+			t.Fatal(err)
+		}
+		for _, l := range levels {
+			buf.Reset()
+			al.Log(l.level, "x")
+			var got struct {
+				Level    string
+				Severity *int
+			}
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("%v: %s", err, buf.String())
+			}
+			want := map[int]int{SeverityScaleNative: l.native, SeverityScaleSyslog: l.syslog}
+			n, numbered := want[scale]
+			if got.Level != l.name || numbered != (got.Severity != nil) || numbered && *got.Severity != n {
+				t.Errorf("scale %d: %s", scale, buf.String())
+			}
+		}
+	}
+	if err := al.SetSeverityScale(SeverityScaleSyslog + 1); err == nil {
+		t.Error("accepted an unknown scale")
+	}
+
+	line := JSONFormatter{SeverityScale: SeverityScaleSyslog}.Format(time.Now(), LevelWarning, "x", nil)
+	if !bytes.Contains(line, []byte(`"severity":4`)) || !bytes.Contains(line, []byte(`"level":"WARN"`)) {
+		t.Errorf("formatter wrote %s", line)
+	}
+}
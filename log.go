@@ -1,13 +1,19 @@
 package logs
 
 import (
+	"encoding/binary"
+	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
 	"fmt"
 	"os"
 	"path"
 	"runtime"
+	"runtime/debug"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 	"io"
 )
 
@@ -25,9 +31,11 @@ const (
 	AdapterFile      = "file"
 )
 
+// levelLoggerImpl 不属于任何级别、一定会输出的log，如通过 Write 写入的内容：
+// 不加级别标记，交给 Logger 时按 LevelError 处理，保证不会被任何 Logger 的级别过滤掉
 const levelLoggerImpl = -1
 
-const  layout = "2006-01-02 15:04:05.123"
+const  layout = "2006-01-02 15:04:05.000"
 
 
 //Logger 接口的定义，包括初始化，写log方式，销毁和刷新
@@ -38,12 +46,83 @@ type Logger interface {
 	Flush()
 }
 
+// LevelWriter 可以查询和修改自身级别的 Logger，用于 SetAdapterLevel 和 EffectiveLevel
+type LevelWriter interface {
+	GetLevel() int
+	SetLevel(level int)
+}
+
+// adapterLevel Logger 的级别，SetAdapterLevel 可能在异步 worker 写log的同时修改它，读写都用原子操作
+type adapterLevel int32
+
+func (l *adapterLevel) get() int {
+	return int(atomic.LoadInt32((*int32)(l)))
+}
+
+func (l *adapterLevel) set(level int) {
+	atomic.StoreInt32((*int32)(l), int32(level))
+}
+
+// Reopener 可以重新打开输出的 Logger，如文件被 logrotate 移走之后重新打开
+type Reopener interface {
+	Reopen() error
+}
+
+// Rotator 可以按需切分输出的 Logger，把当前文件改名归档后开始写新的文件，用于 AppLogger.Rotate
+type Rotator interface {
+	Rotate() error
+}
+
+// batchErrorer 攒成批次发送的 Logger：Flush 和 Destroy 里发送失败时没有下一次写入可以返回错误，
+// AppLogger 在刷新和关闭之后用 takeErr 取出并像写入失败一样报告
+type batchErrorer interface {
+	takeErr() error
+}
+
 // 类型别名，为了获取到实现Logger的类型，如consoleLogger 或者 fileLogger
 type newLoggerFunc func() Logger
 
 
 var levelPrefix = [LevelDebug + 1]string{"[E]", "[W]", "[I]", "[D]"}
 
+// 级别在行首的3种写法，由 SetLevelStyle 选择
+const (
+	LevelStyleShort   = iota // [E] [W] [I] [D]
+	LevelStyleLong           // ERROR WARN INFO DEBUG
+	LevelStyleNumeric        // [0] [1] [2] [3]
+)
+
+var levelTokens = [LevelStyleNumeric + 1][LevelDebug + 1]string{
+	LevelStyleShort:   levelPrefix,
+	LevelStyleLong:    {"ERROR", "WARN", "INFO", "DEBUG"},
+	LevelStyleNumeric: {"[0]", "[1]", "[2]", "[3]"},
+}
+
+// unknownLevelToken 超出范围的级别在行首的写法
+const unknownLevelToken = "[?]"
+
+// validLevel level 是否是 LevelError 到 LevelDebug 之间的已知级别
+func validLevel(level int) bool {
+	return level >= LevelError && level <= LevelDebug
+}
+
+// levelToken 返回 level 按 style 的写法，未知的级别返回 unknownLevelToken 而不是越界 panic
+func levelToken(style int32, level int) string {
+	if !validLevel(level) || style < LevelStyleShort || style > LevelStyleNumeric {
+		return unknownLevelToken
+	}
+	return levelTokens[style][level]
+}
+
+// 级别的名字，用于在配置中按名字指定级别
+var levelNames = map[string]int{
+	"error":   LevelError,
+	"warn":    LevelWarning,
+	"warning": LevelWarning,
+	"info":    LevelInfo,
+	"debug":   LevelDebug,
+}
+
 // 接口池，实现了Logger 接口的接口池
 var adapters = make(map[string]newLoggerFunc)
 
@@ -58,59 +137,193 @@ func Register(name string, log newLoggerFunc) {
 	adapters[name] = log
 }
 
+// adapterType 去掉名字里 '#' 之后的实例名，如 "file#errors" 返回 "file"；
+// 这样同一种 Logger 可以用不同的名字添加多次
+func adapterType(name string) string {
+	if i := strings.IndexByte(name, '#'); i >= 0 {
+		return name[:i]
+	}
+	return name
+}
+
 
 // 整个app log 的结构体,可以包括多个实例化的Logger 类型
 type AppLogger struct {
 	lock                sync.Mutex
-	level               int
-	init                bool
+	levelMask           int32 // 第 n 位为 1 表示级别 n 的log会被写出，见 EnableLevel
+	init                int32 // 已经设置了缺省值，见 ensureInit
+	initLock            sync.Mutex
 	enableFuncCallDepth bool
+	enableFuncName      bool
 	loggerFuncCallDepth int
 	asynchronous        bool
 	prefix              string
 	msgChanLen          int64
 	msgChan             chan *logMsg
-	signalChan          chan string
-	wg                  sync.WaitGroup
+	signalChan          chan asyncSignal
 	outputs             []*nameLogger
+	errorHandler        func(adapterName string, err error)
+	closeTimeout        time.Duration
+	fatalExitCode       int
+	lastErr             lastError
+	errThrottle         errThrottle
+	prefixStack         []string
+	hostPID             []Field // EnableHostPID 开启时为 host 和 pid 两个字段
+	fieldFormat         fieldFormat
+	formatGuard         int32 // 0 关闭，1 开启，2 已经警告过
+	stampAtWrite        int32
+	maxAdapters         int
+	closeOrder          []string
+	enqueueTimeout      int64 // time.Duration
+	droppedCount        uint64
+	inFlight            int64 // 已经交给异步 channel 但还没有写完的log数量，见 WaitIdle
+	seqEnabled          int32
+	seq                 uint64 // 最后一个分配的序号，见 EnableSequence
+	severityScale       int32
+	timeFunc            atomic.Value
+	drainLock           sync.Mutex
+	drainGate           sync.RWMutex
+	drained             bool
+	workers             int
+	workerCtls          []chan chan struct{}
+	workersWg           sync.WaitGroup
+	fallback            *logWriter
+	fallbackCount       uint64
+	sigChan             chan os.Signal
+	sigStop             chan struct{}
+	levelStyle          int32
+	once                onceKeys
+	baseFields          []Field
+	closed              int32
+	audit               int32
+	auditErr            error
+	timePrecision       int64
+	formatter           atomic.Value
+	hooks               levelHooks
+	emptyMsg            string
+	modules             moduleLevels
+	syncFrom            int32 // SyncFrom 的级别 + 1，0 表示没有设置
+	theme               *palette
+	bootstrap           bootstrapBuffer
+	configWatch         configWatch
+	stats               runtimeStats
+}
+
+// 默认写到 stderr 的错误的限流状态，按 Logger 名字和错误信息区分
+type errThrottle struct {
+	sync.Mutex
+	interval time.Duration
+	reports  map[string]*errReport
+}
+
+type errReport struct {
+	last       time.Time
+	suppressed int
+}
+
+const defaultErrReportInterval = 10 * time.Second
+
+// 最近一次 Logger 写入失败的信息
+type lastError struct {
+	sync.Mutex
+	when time.Time
+	name string
+	err  error
 }
 
 
 const defaultAsyncMsgLen = 1e2
 
+const defaultFatalExitCode = 1
+
+// ErrClosed Close 之后再写log时返回
+var ErrClosed = errors.New("logs: logger is closed")
+
+// 写到 fallback 的行的标记
+const fallbackMarker = "[FALLBACK]"
+
 type nameLogger struct {
 	Logger
-	name string
+	name       string
+	mutedUntil int64 // MuteAdapter 设置的静音截止时间，UnixNano
+}
+
+// muted 返回 now 时这个 Logger 是否被静音
+func (l *nameLogger) muted(now time.Time) bool {
+	until := atomic.LoadInt64(&l.mutedUntil)
+	return until != 0 && now.UnixNano() < until
 }
 
 //log的具体内容，包括级别，信息和时间
 type logMsg struct {
-	level int
-	msg   string
-	when  time.Time
+	Record
+	done chan error // LogSync 等待写完的 channel
 }
 
 //协程池
 var logMsgPool *sync.Pool
 
+// putLogMsg 清空 lm 后放回 logMsgPool，避免池里的对象一直引用着参数；
+// 消息、参数和字段都在 Record 里，清空之后池里的 logMsg 大小固定，一阵很长的log过去之后不会留住大块内存
+func putLogMsg(lm *logMsg) {
+	lm.Record = Record{}
+	lm.done = nil
+	logMsgPool.Put(lm)
+}
+
+// Fatal 使用的退出函数，测试中可以替换掉，避免结束测试进程
+var exitFunc = os.Exit
+
 
 
 //实例化APPLogger 
 func NewAppLogger(channelLens ...int64) *AppLogger {
 	al := new(AppLogger)
-	al.level = LevelDebug
-	al.loggerFuncCallDepth = 2
 	al.msgChanLen = append(channelLens, 0)[0]
+	al.ensureInit()
+	return al
+}
+
+// newAppLoggerNoConsole 和 NewAppLogger 一样设置缺省值，但不添加缺省的 console，Logger 由调用者添加
+func newAppLoggerNoConsole() *AppLogger {
+	al := new(AppLogger)
+	al.initDefaults(false)
+	return al
+}
+
+// ensureInit 设置缺省值，没有 Logger 时添加 console，只执行一次；
+// 直接使用零值的 AppLogger 时在第一次写log之前执行
+func (al *AppLogger) ensureInit() {
+	if atomic.LoadInt32(&al.init) != 0 {
+		return
+	}
+	al.initDefaults(true)
+}
+
+// initDefaults 设置缺省值，console 为 true 且没有 Logger 时添加 console，只执行一次
+func (al *AppLogger) initDefaults(console bool) {
+	al.initLock.Lock()
+	defer al.initLock.Unlock()
+	if al.init != 0 {
+		return
+	}
+	atomic.StoreInt32(&al.levelMask, levelsUpTo(LevelDebug))
+	al.loggerFuncCallDepth = 2
+	al.fatalExitCode = defaultFatalExitCode
+	al.workers = 1
 	if al.msgChanLen <= 0 {
 		al.msgChanLen = defaultAsyncMsgLen
 	}
-	al.signalChan = make(chan string, 1)
-	al.setLogger(AdapterConsole)
-	return al
+	al.signalChan = make(chan asyncSignal, 1)
+	if console && len(al.outputs) == 0 {
+		al.setLogger(AdapterConsole)
+	}
+	atomic.StoreInt32(&al.init, 1)
 }
 
 //异步发送log的方法
 func (al *AppLogger) Async(msgLen ...int64) *AppLogger {
+	al.ensureInit()
 	al.lock.Lock()
 	defer al.lock.Unlock()
 	if al.asynchronous {
@@ -126,11 +339,108 @@ func (al *AppLogger) Async(msgLen ...int64) *AppLogger {
 			return &logMsg{}
 		},
 	}
-	al.wg.Add(1)
 	go al.startLogger()
+	al.startWorkers()
+	return al
+}
+
+// ResizeAsyncBuffer 调整异步 channel 的长度，已经在 channel 里的log会原样移到新的 channel 里；
+// n 小于 channel 里现有的log数量时返回错误。同步模式下只修改之后 Async 使用的长度
+func (al *AppLogger) ResizeAsyncBuffer(n int64) error {
+	if n <= 0 {
+		return fmt.Errorf("logs: invalid async buffer length %d", n)
+	}
+	// 阻塞新的log，保证旧 channel 里的log只会减少
+	al.drainGate.Lock()
+	defer al.drainGate.Unlock()
+	if atomic.LoadInt32(&al.closed) != 0 {
+		return ErrClosed
+	}
+
+	al.lock.Lock()
+	if !al.asynchronous {
+		al.msgChanLen = n
+		al.lock.Unlock()
+		return nil
+	}
+	if occupied := int64(len(al.msgChan)); n < occupied {
+		al.lock.Unlock()
+		return fmt.Errorf("logs: async buffer length %d is less than the %d queued messages", n, occupied)
+	}
+	al.msgChanLen = n
+	al.lock.Unlock()
+
+	al.signal("resize")
+	return nil
+}
+
+// resizeMsgChan 在 startLogger 中把旧 channel 里的log移到长度为 msgChanLen 的新 channel
+func (al *AppLogger) resizeMsgChan() {
+	al.stopWorkers()
+	msgChan := make(chan *logMsg, al.msgChanLen)
+	for len(al.msgChan) > 0 {
+		msgChan <- <-al.msgChan
+	}
+	al.msgChan = msgChan
+	al.startWorkers()
+}
+
+// startWorkers 启动 AsyncWorkers 设置的额外消费 goroutine
+func (al *AppLogger) startWorkers() {
+	for i := 1; i < al.workers; i++ {
+		ctl := make(chan chan struct{})
+		al.workerCtls = append(al.workerCtls, ctl)
+		al.workersWg.Add(1)
+		go al.startWorker(ctl)
+	}
+}
+
+// AsyncWorkers 设置异步模式下消费log的 goroutine 数量，需要在 Async 之前调用；
+// n > 1 时不同 goroutine 之间的写入可能交错，log 不再严格按写入顺序输出
+func (al *AppLogger) AsyncWorkers(n int) *AppLogger {
+	al.ensureInit()
+	al.lock.Lock()
+	defer al.lock.Unlock()
+	if !al.asynchronous && n > 0 {
+		al.workers = n
+	}
 	return al
 }
 
+// startWorker 额外的消费 goroutine，只负责写log；flush 和 close 由 startLogger 通过 ctl 协调
+func (al *AppLogger) startWorker(ctl chan chan struct{}) {
+	defer al.workersWg.Done()
+	for {
+		select {
+		case bm := <-al.msgChan:
+			al.consume(bm)
+		case done, ok := <-ctl:
+			if !ok {
+				return
+			}
+			close(done)
+		}
+	}
+}
+
+// syncWorkers 等待所有额外的消费 goroutine 写完手上正在写的log
+func (al *AppLogger) syncWorkers() {
+	for _, ctl := range al.workerCtls {
+		done := make(chan struct{})
+		ctl <- done
+		<-done
+	}
+}
+
+// stopWorkers 停止所有额外的消费 goroutine
+func (al *AppLogger) stopWorkers() {
+	for _, ctl := range al.workerCtls {
+		close(ctl)
+	}
+	al.workersWg.Wait()
+	al.workerCtls = nil
+}
+
 //Logger实例和其配置添加到APPLogger
 func (al *AppLogger) setLogger(adapterName string, configs ...string) error {
 	config := append(configs, "{}")[0]
@@ -141,7 +451,11 @@ func (al *AppLogger) setLogger(adapterName string, configs ...string) error {
 		}
 	}
 
-	logAdapter, ok := adapters[adapterName]
+	if err := al.checkMaxAdapters(); err != nil {
+		return err
+	}
+
+	logAdapter, ok := adapters[adapterType(adapterName)]
 	if !ok {
 		return fmt.Errorf("logs: unknown adaptername %q (forgotten Register?)", adapterName)
 	}
@@ -152,11 +466,13 @@ func (al *AppLogger) setLogger(adapterName string, configs ...string) error {
 		fmt.Fprintln(os.Stderr, "logs.APPLogger.SetLogger: "+err.Error())
 		return err
 	}
+	al.applyTheme(lg)
 	al.outputs = append(al.outputs, &nameLogger{name: adapterName, Logger: lg})
+	al.replayBootstrap(lg)
 	return nil
 }
 
-
+// AddLogger 添加一个已注册的 Logger；同一种 Logger 要添加多次时用 '#' 加上实例名区分，如 "file#errors" 和 "file#app"
 func (al *AppLogger) AddLogger(adapterName string, configs ...string) (error) {
 	err := al.setLogger(adapterName,configs...)
 	if err != nil {
@@ -164,9 +480,104 @@ func (al *AppLogger) AddLogger(adapterName string, configs ...string) (error) {
 	}
 	//al.outputs = al.outputs[1:]
 	//fmt.Println(al.outputs[0])
-	return nil 
+	return nil
+}
+
+// AddWriter 直接把一个 io.Writer 作为名为 name 的 Logger 添加到APPLogger，不需要先 Register
+func (al *AppLogger) AddWriter(name string, w io.Writer, level int) error {
+	return al.AddAdapter(name, NewWriterAdapter(w, level))
+}
+
+// AddAdapter 直接把已经初始化好的 lg 作为名为 name 的 Logger 添加到APPLogger，不需要先 Register，
+// 用于在其他包里实现的 Logger，如 logstest 的 Capture
+func (al *AppLogger) AddAdapter(name string, lg Logger) error {
+	for _, l := range al.outputs {
+		if l.name == name {
+			return fmt.Errorf("logs: duplicate adaptername %q (you have set this logger before)", name)
+		}
+	}
+	if err := al.checkMaxAdapters(); err != nil {
+		return err
+	}
+	al.applyTheme(lg)
+	al.outputs = append(al.outputs, &nameLogger{name: name, Logger: lg})
+	al.replayBootstrap(lg)
+	return nil
+}
+
+// SetMaxAdapters 设置最多可以添加的 Logger 个数，超过时 AddLogger、AddWriter 和 AddAdapter 返回错误；n <= 0 表示不限制
+func (al *AppLogger) SetMaxAdapters(n int) {
+	al.lock.Lock()
+	al.maxAdapters = n
+	al.lock.Unlock()
+}
+
+func (al *AppLogger) checkMaxAdapters() error {
+	al.lock.Lock()
+	max := al.maxAdapters
+	al.lock.Unlock()
+	if max > 0 && len(al.outputs) >= max {
+		return fmt.Errorf("logs: too many adapters (max %d)", max)
+	}
+	return nil
 }
 
+// SetAdapterLevel 修改名为 name 的 Logger 的级别，Logger 需要实现 LevelWriter
+func (al *AppLogger) SetAdapterLevel(name string, level int) error {
+	for _, l := range al.outputs {
+		if l.name != name {
+			continue
+		}
+		lw, ok := l.Logger.(LevelWriter)
+		if !ok {
+			return fmt.Errorf("logs: adapter %q does not implement LevelWriter", name)
+		}
+		lw.SetLevel(level)
+		return nil
+	}
+	return fmt.Errorf("logs: unknown adaptername %q", name)
+}
+
+// MuteAdapter 在 d 时间内不再把log写给名为 name 的 Logger，时间到后自动恢复，也可以用 UnmuteAdapter 提前恢复
+func (al *AppLogger) MuteAdapter(name string, d time.Duration) error {
+	for _, l := range al.outputs {
+		if l.name == name {
+			atomic.StoreInt64(&l.mutedUntil, time.Now().Add(d).UnixNano())
+			return nil
+		}
+	}
+	return fmt.Errorf("logs: unknown adaptername %q", name)
+}
+
+// UnmuteAdapter 恢复被 MuteAdapter 静音的 Logger
+func (al *AppLogger) UnmuteAdapter(name string) error {
+	for _, l := range al.outputs {
+		if l.name == name {
+			atomic.StoreInt64(&l.mutedUntil, 0)
+			return nil
+		}
+	}
+	return fmt.Errorf("logs: unknown adaptername %q", name)
+}
+
+// EffectiveLevel 返回实际会被写出的最高级别，即 AppLogger 的级别和所有 Logger 中最高级别的较小值，
+// 没有实现 LevelWriter 的 Logger 按 LevelDebug 计算
+func (al *AppLogger) EffectiveLevel() int {
+	union := -1
+	for _, l := range al.outputs {
+		level := LevelDebug
+		if lw, ok := l.Logger.(LevelWriter); ok {
+			level = lw.GetLevel()
+		}
+		if level > union {
+			union = level
+		}
+	}
+	if level := al.getLevel(); level < union {
+		return level
+	}
+	return union
+}
 
 func (al *AppLogger) RemoveLogger(adapterName string) (error) {	
 	for k,lg := range al.outputs {
@@ -178,25 +589,45 @@ func (al *AppLogger) RemoveLogger(adapterName string) (error) {
 	return nil 
 }
 
+// asyncSignal 发给 startLogger 的信号，处理完之后关闭 done；每次发送都带着自己的 done，
+// 多个 goroutine 同时 Flush 时各自等自己的信号处理完
+type asyncSignal struct {
+	name string
+	done chan struct{}
+}
+
+// signal 把 name 信号发给 startLogger 并等待处理完
+func (al *AppLogger) signal(name string) {
+	done := make(chan struct{})
+	al.signalChan <- asyncSignal{name: name, done: done}
+	<-done
+}
+
 // 异步启动 logget
 func (al *AppLogger) startLogger() {
 	gameOver := false
 	for {
 		select {
 		case bm := <-al.msgChan:
-			al.writeToLoggers(bm.when, bm.msg, bm.level)
-			logMsgPool.Put(bm)
+			al.consume(bm)
 		case sg := <-al.signalChan:
-			// Now should only send "flush" or "close" to bl.signalChan
-			al.flush()
-			if sg == "close" {
-				for _, l := range al.outputs {
-					l.Destroy()
-				}
-				al.outputs = nil
+			// Now should only send "flush", "sync", "resize" or "close" to bl.signalChan
+			switch sg.name {
+			case "close":
+				al.stopWorkers()
+				al.drainMsgChan()
+				al.destroyOutputs()
 				gameOver = true
+			case "sync":
+				// 只写出排队的log，不刷新 Logger
+				al.drainMsgChan()
+				al.syncWorkers()
+			case "resize":
+				al.resizeMsgChan()
+			default:
+				al.flush()
 			}
-			al.wg.Done()
+			close(sg.done)
 		}
 		if gameOver {
 			break
@@ -205,10 +636,41 @@ func (al *AppLogger) startLogger() {
 }
 
 func (al *AppLogger) Flush() {
+	al.ensureInit()
+	// 和 Close 互斥，避免向已经关闭的 signalChan 发送
+	al.drainGate.RLock()
+	defer al.drainGate.RUnlock()
+	al.flushOutputs()
+}
+
+// FlushAdapter 先写出异步 channel 里排队的log，然后只刷新名为 name 的 Logger，不影响其他 Logger 的缓冲；
+// 刷新时发送批次失败（如 cloudwatch）时返回这个错误
+func (al *AppLogger) FlushAdapter(name string) error {
+	al.ensureInit()
+	al.drainGate.RLock()
+	defer al.drainGate.RUnlock()
+	for _, l := range al.outputs {
+		if l.name != name {
+			continue
+		}
+		if atomic.LoadInt32(&al.closed) != 0 {
+			return ErrClosed
+		}
+		if al.asynchronous {
+			al.signal("sync")
+		}
+		return al.flushLogger(l)
+	}
+	return fmt.Errorf("logs: unknown adaptername %q", name)
+}
+
+// flushOutputs 同 Flush，调用方需持有 drainGate
+func (al *AppLogger) flushOutputs() {
+	if atomic.LoadInt32(&al.closed) != 0 {
+		return
+	}
 	if al.asynchronous {
-		al.signalChan <- "flush"
-		al.wg.Wait()
-		al.wg.Add(1)
+		al.signal("flush")
 		return
 	}
 	al.flush()
@@ -216,133 +678,1089 @@ func (al *AppLogger) Flush() {
 
 
 
-func (al *AppLogger) flush() {
-	if al.asynchronous {
-		for {
-			if len(al.msgChan) > 0 {
-				bm := <-al.msgChan
-				al.writeToLoggers(bm.when, bm.msg, bm.level)
-				logMsgPool.Put(bm)
-				continue
-			}
-			break
-		}
+// Drain 写出所有还在异步 channel 里的log并刷新所有 Logger，之后新的log会阻塞，直到调用 Resume，
+// 用于切换文件或重新配置时避免log交错；不要在 Drain 和 Resume 之间在同一个 goroutine 里写log
+func (al *AppLogger) Drain() {
+	al.drain()
+}
+
+// drain 同 Drain，已经处于 Drain 状态时返回 false
+func (al *AppLogger) drain() bool {
+	al.drainLock.Lock()
+	defer al.drainLock.Unlock()
+	if al.drained {
+		return false
+	}
+	al.drained = true
+	al.drainGate.Lock()
+	al.flushOutputs()
+	return true
+}
+
+// Resume 恢复 Drain 之后被阻塞的log
+func (al *AppLogger) Resume() {
+	al.drainLock.Lock()
+	defer al.drainLock.Unlock()
+	if !al.drained {
+		return
 	}
+	al.drained = false
+	al.drainGate.Unlock()
+}
+
+func (al *AppLogger) flush() {
+	al.drainMsgChan()
+	al.syncWorkers()
 	for _, l := range al.outputs {
-		l.Flush()
+		al.flushLogger(l)
 	}
 }
 
+// flushLogger 刷新 l，返回并报告它刷新时发送批次失败的错误
+func (al *AppLogger) flushLogger(l *nameLogger) error {
+	l.Flush()
+	return al.reportBatchErr(l)
+}
 
-//同步写日志函数，logger 实例需要实现 WriteMsg 函数
-func (al *AppLogger) writeToLoggers(when time.Time, msg string, level int) {
-	for _, l := range al.outputs {
-		err := l.WriteMsg(when, msg, level)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "unable to WriteMsg to adapter:%v,error:%v\n", l.name, err)
-		}
+// reportBatchErr 取出 l 在 Flush 或 Destroy 里发送批次失败的错误，和 writeToLoggers 里的写入失败一样报告
+func (al *AppLogger) reportBatchErr(l *nameLogger) error {
+	b, ok := l.Logger.(batchErrorer)
+	if !ok {
+		return nil
 	}
+	err := b.takeErr()
+	if err == nil {
+		return nil
+	}
+	al.setLastError(l.name, err)
+	al.reportError(l.name, err)
+	err = fmt.Errorf("logs: adapter %s: %v", l.name, err)
+	al.recordAuditErr(err)
+	return err
+}
 
-	
+// 把调用时已经在异步 channel 里的 log 写出；只取调用时的条数，
+// 其他 goroutine 一直在写log时也能结束，之后进来的留给 startLogger 按顺序处理
+func (al *AppLogger) drainMsgChan() {
+	if !al.asynchronous {
+		return
+	}
+	for n := len(al.msgChan); n > 0; n-- {
+		select {
+		case bm := <-al.msgChan:
+			al.consume(bm)
+		default:
+			// 剩下的已经被其他 worker 取走
+			return
+		}
+	}
 }
 
+// SetCloseTimeout 设置 Close 时每个 Logger Flush 和 Destroy 各自的最长等待时间，超时的 Logger 会被放弃，d <= 0 表示不限时
+func (al *AppLogger) SetCloseTimeout(d time.Duration) {
+	al.lock.Lock()
+	al.closeTimeout = d
+	al.lock.Unlock()
+}
 
-//写日志的主要函数，支持同步写和异步写
-func (al *AppLogger) writeMsg(logLevel int, msg string, v ...interface{}) error {
-	/*if !al.init {
-		al.lock.Lock()
-		al.setLogger(AdapterConsole)
-		al.lock.Unlock()
-	}*/
+// 按 SetCloseOrder 的顺序 Flush 所有 Logger，再按相反的顺序 Destroy，超时的通过 error handler 报告
+func (al *AppLogger) destroyOutputs() {
+	outputs := al.closeOrdered()
+	for _, l := range outputs {
+		al.closeStep(l, "flush", l.Flush)
+	}
+	for i := len(outputs) - 1; i >= 0; i-- {
+		al.closeStep(outputs[i], "destroy", outputs[i].Destroy)
+	}
+	for _, l := range outputs {
+		al.reportBatchErr(l)
+	}
+	al.outputs = nil
+}
 
-	if len(v) > 0 {
-		msg = fmt.Sprintf(msg, v...)
-		//fmt.Println(msg)
+// closeStep 执行 l 的 Flush 或 Destroy，超过 closeTimeout 就放弃等待
+func (al *AppLogger) closeStep(l *nameLogger, step string, fn func()) {
+	// 审计模式下必须等每个 Logger 都写完
+	if al.closeTimeout <= 0 || al.auditMode() {
+		fn()
+		return
 	}
+	done := make(chan struct{})
+	go func() {
+		fn()
+		close(done)
+	}()
+	timer := time.NewTimer(al.closeTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		al.reportError(l.name, fmt.Errorf("logs: %s timed out after %v", step, al.closeTimeout))
+	}
+}
 
-	msg = al.prefix + " " + msg
+// SetCloseOrder 设置 Close 时刷新 Logger 的顺序：names 中的 Logger 按给出的顺序排在前面，其余的按添加的顺序排在后面；
+// 所有 Logger 都刷新完之后再按相反的顺序销毁。默认按添加的顺序刷新
+func (al *AppLogger) SetCloseOrder(names ...string) {
+	al.lock.Lock()
+	al.closeOrder = append([]string(nil), names...)
+	al.lock.Unlock()
+}
 
-	when := time.Now()
-	if al.enableFuncCallDepth {
-		_, file, line, ok := runtime.Caller(al.loggerFuncCallDepth)
-		if !ok {
-			file = "???"
-			line = 0
+// closeOrdered 返回按 SetCloseOrder 排好序的 Logger
+func (al *AppLogger) closeOrdered() []*nameLogger {
+	al.lock.Lock()
+	order := al.closeOrder
+	al.lock.Unlock()
+	ordered := make([]*nameLogger, 0, len(al.outputs))
+	picked := make(map[*nameLogger]bool)
+	for _, name := range order {
+		for _, l := range al.outputs {
+			if l.name == name && !picked[l] {
+				ordered = append(ordered, l)
+				picked[l] = true
+			}
 		}
-		_, filename := path.Split(file)
-		msg = "[" + filename + ":" + strconv.Itoa(line) + "] " + msg
 	}
-
-	//set level info in front of filename info
-	if logLevel == levelLoggerImpl {
-		// set to emergency to ensure all log will be print out correctly
-		logLevel = LevelDebug
-	} else {
-		msg = levelPrefix[logLevel] + " " + msg
+	for _, l := range al.outputs {
+		if !picked[l] {
+			ordered = append(ordered, l)
+		}
 	}
+	return ordered
+}
 
-	// 异步写实现
-	if al.asynchronous {
-		lm := logMsgPool.Get().(*logMsg)
-		lm.level = logLevel
-		lm.msg = msg
-		lm.when = when
-		if al.outputs != nil {
-			al.msgChan <- lm
+
+//同步写日志函数，实现了 RecordWriter 的 logger 调用 WriteRecord，其它的调用 WriteMsg
+func (al *AppLogger) writeToLoggers(r *Record) error {
+	failed, written := 0, 0
+	toFallback := false
+	var firstErr error
+	formatted := al.format(r)
+	now := time.Now()
+	for _, l := range al.outputs {
+		if l.muted(now) {
+			continue
+		}
+		written++
+		err := safeWriteRecord(l.Logger, r, formatted)
+		if err == errBreakerOpen {
+			// 断路器打开，这条log改写到 fallback
+			toFallback = true
+			continue
+		}
+		if err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = fmt.Errorf("logs: adapter %s: %v", l.name, err)
+				al.recordAuditErr(firstErr)
+			}
+			al.setLastError(l.name, err)
+			al.reportError(l.name, err)
 		} else {
-			logMsgPool.Put(lm)
+			al.clearLastError(l.name)
 		}
-	} else {
-		al.writeToLoggers(when, msg, logLevel)
 	}
-	return nil
+	if toFallback || failed > 0 && failed == written {
+		al.writeFallback(r)
+	}
+	al.bootstrap.add(r)
+	al.hooks.fire(r)
+	return firstErr
 }
 
-func (al *AppLogger) Close() {
-	if al.asynchronous {
-		al.signalChan <- "close"
-		al.wg.Wait()
-		close(al.msgChan)
-	} else {
-		al.flush()
-		for _, l := range al.outputs {
-			l.Destroy()
-		}
-		al.outputs = nil
+// format 设置了 Formatter 时返回 r 格式化之后的内容，否则返回 nil
+func (al *AppLogger) format(r *Record) []byte {
+	f := al.getFormatter()
+	if f == nil {
+		return nil
 	}
-	close(al.signalChan)
+	level := r.Level
+	if r.noLevel {
+		level = levelLoggerImpl
+	}
+	return f.Format(r.When, level, r.formatterMsg(), r.Fields)
 }
 
+// safeWriteRecord 同 writeRecord，lg 写入时 panic 的话转成错误返回，避免异步写log的 goroutine 退出
+func safeWriteRecord(lg Logger, r *Record, formatted []byte) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return writeRecord(lg, r, formatted)
+}
 
-
-
-func (al *AppLogger) Info(format string, v ...interface{}) {
-	if LevelInfo > al.level {
-		return
+// writeRecord 把 r 写到 lg，formatted 为 Formatter 的结果
+func writeRecord(lg Logger, r *Record, formatted []byte) error {
+	if q, ok := lg.(*queuedLogger); ok {
+		return q.enqueue(r, formatted)
+	}
+	if b, ok := lg.(*breakerLogger); ok {
+		return b.write(r, formatted)
+	}
+	if formatted != nil {
+		if rw, ok := lg.(RawWriter); ok {
+			return rw.WriteRaw(formatted, r.Level)
+		}
+		return lg.WriteMsg(r.When, strings.TrimSuffix(string(formatted), "\n"), r.Level)
 	}
-	al.writeMsg(LevelInfo, format, v...)
+	if rw, ok := lg.(RecordWriter); ok {
+		return rw.WriteRecord(r)
+	}
+	return lg.WriteMsg(r.When, r.text, r.Level)
 }
 
-func (al *AppLogger) Warn(format string, v ...interface{}) {
-	if LevelWarning > al.level {
+// SetFallback 设置所有 Logger 都写入失败时使用的 w，如 os.Stderr 或本地文件，写入的行以 "[FALLBACK]" 开头；w 为 nil 时关闭
+func (al *AppLogger) SetFallback(w io.Writer) {
+	al.lock.Lock()
+	defer al.lock.Unlock()
+	if w == nil {
+		al.fallback = nil
 		return
 	}
-	al.writeMsg(LevelWarning, format, v...)
+	al.fallback = newLogWriter(w)
+}
+
+// FallbackCount 返回写到 fallback 的log条数
+func (al *AppLogger) FallbackCount() uint64 {
+	return atomic.LoadUint64(&al.fallbackCount)
+}
+
+func (al *AppLogger) writeFallback(r *Record) {
+	al.lock.Lock()
+	fallback := al.fallback
+	al.lock.Unlock()
+	if fallback == nil {
+		return
+	}
+	atomic.AddUint64(&al.fallbackCount, 1)
+	fallback.writeln(r.When, fallbackMarker+" "+r.text)
+}
+
+// LastError 返回最近一次 Logger 写入失败的时间、Logger 名字和错误，同一个 Logger 之后写入成功会清除，没有错误时 err 为 nil
+func (al *AppLogger) LastError() (time.Time, string, error) {
+	al.lastErr.Lock()
+	defer al.lastErr.Unlock()
+	return al.lastErr.when, al.lastErr.name, al.lastErr.err
+}
+
+func (al *AppLogger) setLastError(adapterName string, err error) {
+	al.lastErr.Lock()
+	al.lastErr.when = time.Now()
+	al.lastErr.name = adapterName
+	al.lastErr.err = err
+	al.lastErr.Unlock()
+}
+
+func (al *AppLogger) clearLastError(adapterName string) {
+	al.lastErr.Lock()
+	if al.lastErr.err != nil && al.lastErr.name == adapterName {
+		al.lastErr.when = time.Time{}
+		al.lastErr.name = ""
+		al.lastErr.err = nil
+	}
+	al.lastErr.Unlock()
+}
+
+// SetErrorHandler 设置 Logger 出错时的处理函数，默认写到 stderr
+func (al *AppLogger) SetErrorHandler(fn func(adapterName string, err error)) {
+	al.lock.Lock()
+	al.errorHandler = fn
+	al.lock.Unlock()
+}
+
+// 报告 Logger 的错误
+func (al *AppLogger) reportError(adapterName string, err error) {
+	if al.errorHandler != nil {
+		al.errorHandler(adapterName, err)
+		return
+	}
+	key := adapterName + ":" + err.Error()
+	al.errThrottle.Lock()
+	defer al.errThrottle.Unlock()
+	if al.errThrottle.reports == nil {
+		al.errThrottle.reports = make(map[string]*errReport)
+	}
+	now := time.Now()
+	r, ok := al.errThrottle.reports[key]
+	if ok && now.Sub(r.last) < al.errReportInterval() {
+		r.suppressed++
+		return
+	}
+	if !ok {
+		r = &errReport{}
+		al.errThrottle.reports[key] = r
+	}
+	if r.suppressed > 0 {
+		fmt.Fprintf(os.Stderr, "logs: adapter:%v,error:%v (suppressed %d similar errors)\n", adapterName, err, r.suppressed)
+	} else {
+		fmt.Fprintf(os.Stderr, "logs: adapter:%v,error:%v\n", adapterName, err)
+	}
+	r.last = now
+	r.suppressed = 0
+}
+
+// SetErrorReportInterval 设置同一个 Logger 的相同错误写到 stderr 的最小间隔，间隔内的重复错误只计数，
+// 下次报告时一起给出被忽略的次数；d <= 0 时使用默认的 defaultErrReportInterval
+func (al *AppLogger) SetErrorReportInterval(d time.Duration) {
+	al.errThrottle.Lock()
+	al.errThrottle.interval = d
+	al.errThrottle.Unlock()
+}
+
+func (al *AppLogger) errReportInterval() time.Duration {
+	if al.errThrottle.interval <= 0 {
+		return defaultErrReportInterval
+	}
+	return al.errThrottle.interval
+}
+
+
+//写日志的主要函数，支持同步写和异步写
+func (al *AppLogger) writeMsg(logLevel int, fields []Field, msg string, v ...interface{}) error {
+	return al.output(1, true, logLevel, fields, msg, v)
+}
+
+// output 同 writeMsg，skip 为 output 和 Info 这一类函数之间多出来的栈帧数，用来算出调用位置；
+// checkModule 为 false 时调用方已经按自己的级别（如 Child 单独设置的级别）判断过，不再按模块级别检查
+func (al *AppLogger) output(skip int, checkModule bool, logLevel int, fields []Field, msg string, v []interface{}) error {
+	al.ensureInit()
+	if checkModule && !al.moduleAllows(skip, logLevel) {
+		return nil
+	}
+	if msg == "" && len(v) == 0 && len(fields) == 0 {
+		if msg = al.emptyMsgPlaceholder(); msg == "" {
+			return nil
+		}
+	}
+	return al.emit(al.newRecord(skip, logLevel, fields, msg, v))
+}
+
+// emit 把 r 交给 dispatch，SyncFrom 的级别及更严重的log等到写完才返回
+func (al *AppLogger) emit(r Record) error {
+	if max := atomic.LoadInt32(&al.syncFrom); !r.noLevel && int32(r.Level) < max {
+		done := make(chan error, 1)
+		al.dispatch(r, done)
+		return <-done
+	}
+	return al.dispatch(r, nil)
+}
+
+// SyncFrom 让 level 及更严重级别的log同步写出：等到写到所有 Logger 并刷新之后才返回，
+// 异步模式下之前排队的log也会先写出；更低级别的log不受影响。level 为 -1 时关闭
+func (al *AppLogger) SyncFrom(level int) {
+	atomic.StoreInt32(&al.syncFrom, int32(level+1))
+}
+
+// 同 writeMsg，但要等到log写到所有 Logger 并刷新之后才返回，返回写入时的错误
+func (al *AppLogger) writeMsgWait(logLevel int, fields []Field, msg string, v ...interface{}) error {
+	al.ensureInit()
+	if !al.moduleAllows(0, logLevel) {
+		return nil
+	}
+	if msg == "" && len(v) == 0 && len(fields) == 0 {
+		if msg = al.emptyMsgPlaceholder(); msg == "" {
+			return nil
+		}
+	}
+	done := make(chan error, 1)
+	al.dispatch(al.newRecord(0, logLevel, fields, msg, v), done)
+	return <-done
+}
+
+// SetEmptyMsgPlaceholder 设置空消息（格式为空、没有参数也没有字段）时写出的内容，如 "<empty>"；
+// 默认为空，空消息不写出
+func (al *AppLogger) SetEmptyMsgPlaceholder(placeholder string) {
+	al.lock.Lock()
+	al.emptyMsg = placeholder
+	al.lock.Unlock()
+}
+
+func (al *AppLogger) emptyMsgPlaceholder() string {
+	al.lock.Lock()
+	defer al.lock.Unlock()
+	return al.emptyMsg
+}
+
+// 生成一条log的 Record，只能由 writeMsg 这一类函数直接调用，否则调用位置会算错；skip 见 output
+func (al *AppLogger) newRecord(skip int, logLevel int, fields []Field, msg string, v []interface{}) Record {
+	var r Record
+	if al.enableFuncCallDepth {
+		// 多一层是 writeMsg 本身
+		pc, file, line, ok := runtime.Caller(al.loggerFuncCallDepth + 1 + skip)
+		if !ok {
+			file = "???"
+			line = 0
+		}
+		_, r.File = path.Split(file)
+		r.Line = line
+		if al.enableFuncName && ok {
+			r.Func = funcName(pc)
+		}
+	}
+	al.fillRecord(&r, logLevel, fields, msg, v)
+	return r
+}
+
+// fillRecord 填写 r 除调用位置以外的内容并生成文本，r.File 不为空时在文本里加上调用位置
+func (al *AppLogger) fillRecord(r *Record, logLevel int, fields []Field, msg string, v []interface{}) {
+	r.Level, r.Format, r.Args, r.Msg = logLevel, msg, v, formatMsg(msg, v)
+	prefix := al.prefix
+	if p := al.contextPrefix(); p != "" {
+		prefix += " " + p
+	}
+	r.Prefix = strings.TrimSpace(prefix)
+	msg = prefix + " " + r.Msg
+	fields = al.withBaseFields(fields)
+	if len(fields) > 0 {
+		r.Fields = make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			r.Fields[f.Key] = f.Value
+		}
+		msg += " " + al.getFieldFormat().format(fields)
+	}
+
+	r.precision = time.Duration(atomic.LoadInt64(&al.timePrecision))
+	r.severityScale = int(atomic.LoadInt32(&al.severityScale))
+	r.When = al.stamp(time.Now(), r.precision)
+	if r.File != "" {
+		caller := r.File + ":" + strconv.Itoa(r.Line)
+		if r.Func != "" {
+			caller += " " + r.Func
+		}
+		msg = "[" + caller + "] " + msg
+	}
+
+	//set level info in front of filename info
+	if logLevel == levelLoggerImpl {
+		// set to the most severe level to ensure all log will be print out correctly
+		r.Level = LevelError
+		r.noLevel = true
+	} else {
+		msg = levelToken(atomic.LoadInt32(&al.levelStyle), logLevel) + " " + msg
+	}
+	r.text = msg
+}
+
+// LogFrom 以 level 级别写 msg，调用位置使用给出的 file:line 而不是实际调用 LogFrom 的位置，
+// 用于错误本身带着出错位置的场景；不管是否开启了 EnableFuncCallDepth 都会写出调用位置
+func (al *AppLogger) LogFrom(file string, line int, level int, msg string) {
+	if !al.Enabled(level) {
+		return
+	}
+	al.ensureInit()
+	// 和 output 一样检查模块级别、空消息和 SyncFrom；直接由 LogFrom 调用，比 output 少了 writeMsg 和 Log 两层
+	if !al.moduleAllows(-1, level) {
+		return
+	}
+	if msg == "" {
+		if msg = al.emptyMsgPlaceholder(); msg == "" {
+			return
+		}
+	}
+	r := Record{File: file, Line: line}
+	al.fillRecord(&r, level, nil, msg, nil)
+	al.emit(r)
+}
+
+// 把 r 交给异步 channel 或者直接写出；done 不为 nil 时，写完并刷新所有 Logger 之后把写入的错误发到 done
+func (al *AppLogger) dispatch(r Record, done chan error) error {
+	// Drain 之后到 Resume 之前阻塞在这里
+	al.drainGate.RLock()
+	defer al.drainGate.RUnlock()
+
+	if atomic.LoadInt32(&al.closed) != 0 {
+		al.writeFallback(&r)
+		if done != nil {
+			done <- ErrClosed
+		}
+		return ErrClosed
+	}
+	al.dispatchLocked(r, done)
+	return nil
+}
+
+// enqueue 把 lm 放进异步 channel；设置了 SetEnqueueTimeout 时最多等待这么久，超时就丢弃 lm，
+// 审计模式下和需要等待写完的 lm 一直阻塞
+func (al *AppLogger) enqueue(lm *logMsg) {
+	atomic.AddInt64(&al.inFlight, 1)
+	d := time.Duration(atomic.LoadInt64(&al.enqueueTimeout))
+	if d <= 0 || lm.done != nil || al.auditMode() {
+		al.msgChan <- lm
+		return
+	}
+	select {
+	case al.msgChan <- lm:
+		return
+	default:
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case al.msgChan <- lm:
+	case <-timer.C:
+		atomic.AddUint64(&al.droppedCount, 1)
+		atomic.AddInt64(&al.inFlight, -1)
+		al.reportError("async", fmt.Errorf("logs: async queue full for %v, message dropped", d))
+		putLogMsg(lm)
+	}
+}
+
+// SetEnqueueTimeout 设置异步模式下队列满时写log最多等待的时间，超时的log被丢弃并通过 error handler 报告，
+// 丢弃的条数由 DroppedCount 返回；d <= 0（默认）时一直等待。审计模式下不会丢弃
+func (al *AppLogger) SetEnqueueTimeout(d time.Duration) {
+	atomic.StoreInt64(&al.enqueueTimeout, int64(d))
+}
+
+// DroppedCount 返回因为 SetEnqueueTimeout 超时被丢弃的log条数
+func (al *AppLogger) DroppedCount() uint64 {
+	return atomic.LoadUint64(&al.droppedCount)
+}
+
+// EnableSequence 为 true 时给每条log分配一个递增的序号，写在级别后面，如 "[I] #000001 msg"，
+// JSON 输出中为 seq 字段，用于检查传输过程中有没有丢失或者乱序。序号在log进入队列时分配，
+// 每个 AppLogger 单独计数，从 1 开始；关闭之后再打开接着原来的序号，进程重启后重新从 1 开始
+func (al *AppLogger) EnableSequence(b bool) {
+	v := int32(0)
+	if b {
+		v = 1
+	}
+	atomic.StoreInt32(&al.seqEnabled, v)
+}
+
+// numberRecord 开启了 EnableSequence 时给 r 分配序号
+func (al *AppLogger) numberRecord(r *Record) {
+	if atomic.LoadInt32(&al.seqEnabled) == 0 {
+		return
+	}
+	r.Seq = atomic.AddUint64(&al.seq, 1)
+	seq := seqToken(r.Seq)
+	if i := strings.IndexByte(r.text, ' '); i >= 0 && !r.noLevel {
+		// 放在级别后面，不影响按行首的级别上色
+		r.text = r.text[:i+1] + seq + r.text[i:]
+		return
+	}
+	r.text = seq + " " + r.text
+}
+
+// seqToken 序号在行中的写法，至少6位
+func seqToken(seq uint64) string {
+	return fmt.Sprintf("#%06d", seq)
+}
+
+// dispatchLocked 把 r 交给异步 channel 或者直接写出，调用时需持有 drainGate 的读锁
+func (al *AppLogger) dispatchLocked(r Record, done chan error) {
+	al.numberRecord(&r)
+	// 异步写实现
+	if al.asynchronous {
+		lm := logMsgPool.Get().(*logMsg)
+		lm.Record = r
+		lm.done = done
+		if al.outputs != nil {
+			al.enqueue(lm)
+		} else {
+			al.writeLogMsg(lm)
+		}
+		return
+	}
+	err := al.writeToLoggers(&r)
+	if done != nil {
+		for _, l := range al.outputs {
+			if ferr := al.flushLogger(l); err == nil {
+				err = ferr
+			}
+		}
+		done <- err
+	}
+}
+
+// writeLogMsg 写出从异步 channel 取出的 lm 并放回 logMsgPool
+func (al *AppLogger) writeLogMsg(lm *logMsg) {
+	if atomic.LoadInt32(&al.stampAtWrite) != 0 {
+		lm.When = al.stamp(time.Now(), lm.precision)
+	}
+	err := al.writeToLoggers(&lm.Record)
+	if lm.done != nil {
+		for _, l := range al.outputs {
+			if ferr := al.flushLogger(l); err == nil {
+				err = ferr
+			}
+		}
+		lm.done <- err
+	}
+	putLogMsg(lm)
+}
+
+// consume 写出从异步 channel 取出的 lm，写完之后才算不在队列中
+func (al *AppLogger) consume(lm *logMsg) {
+	al.writeLogMsg(lm)
+	atomic.AddInt64(&al.inFlight, -1)
+}
+
+// WaitIdle 等待异步 channel 里的log全部写完，包括已经取出正在写的，最多等 timeout，超时返回错误；
+// timeout <= 0 表示不限时。和 Flush 不同，它只观察而不主动写出或刷新 Logger，同步模式下立即返回
+func (al *AppLogger) WaitIdle(timeout time.Duration) error {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	wait := time.Millisecond
+	for {
+		n := atomic.LoadInt64(&al.inFlight)
+		if n <= 0 {
+			return nil
+		}
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			return fmt.Errorf("logs: %d messages still queued after %v", n, timeout)
+		}
+		time.Sleep(wait)
+		if wait < 10*time.Millisecond {
+			wait *= 2
+		}
+	}
+}
+
+// SetStampAtWrite 为 true 时异步模式下log的时间取写到 Logger 的时刻，而不是调用 Info 等函数的时刻，
+// 可以看出log在队列里等了多久；代价是时间不再表示事件发生的时刻，多个 worker 时时间也不一定按顺序。
+// 默认为 false，同步模式下两者相同
+func (al *AppLogger) SetStampAtWrite(b bool) {
+	v := int32(0)
+	if b {
+		v = 1
+	}
+	atomic.StoreInt32(&al.stampAtWrite, v)
+}
+
+// SetAuditMode 开启审计模式：异步模式下队列满时一直阻塞而不会丢弃log，Close 一定等所有log写完并刷新，
+// 不受 SetCloseTimeout 限制，并记录第一个写入失败的错误，由 CloseErr 返回
+func (al *AppLogger) SetAuditMode(b bool) {
+	v := int32(0)
+	if b {
+		v = 1
+	}
+	atomic.StoreInt32(&al.audit, v)
+}
+
+func (al *AppLogger) auditMode() bool {
+	return atomic.LoadInt32(&al.audit) != 0
+}
+
+func (al *AppLogger) recordAuditErr(err error) {
+	if !al.auditMode() {
+		return
+	}
+	al.lock.Lock()
+	if al.auditErr == nil {
+		al.auditErr = err
+	}
+	al.lock.Unlock()
+}
+
+// CloseErr 同 Close，审计模式下返回期间第一个写入失败的错误
+func (al *AppLogger) CloseErr() error {
+	al.Close()
+	al.lock.Lock()
+	defer al.lock.Unlock()
+	return al.auditErr
+}
+
+// Close 写出剩余的log并销毁所有 Logger，重复调用无效；之后再写的log会被丢弃，设置了 fallback 时写到 fallback
+func (al *AppLogger) Close() {
+	al.ensureInit()
+	if !atomic.CompareAndSwapInt32(&al.closed, 0, 1) {
+		return
+	}
+	// 先恢复 Drain 阻塞的log，后台的 goroutine 可能正阻塞在写log上，不恢复就等不到它们退出
+	al.Resume()
+	al.stopConfigWatch()
+	al.stats.stop()
+	// 等正在发送的log发完，之后的log会看到 closed，不会再发送到已经关闭的 channel
+	al.drainGate.Lock()
+	defer al.drainGate.Unlock()
+	if al.asynchronous {
+		al.signal("close")
+		close(al.msgChan)
+	} else {
+		al.destroyOutputs()
+	}
+	close(al.signalChan)
+	al.hooks.close()
+}
+
+
+
+
+func (al *AppLogger) Info(format string, v ...interface{}) {
+	if !al.Enabled(LevelInfo) {
+		return
+	}
+	al.guardFormat(format, v)
+	al.writeMsg(LevelInfo, nil, format, v...)
+}
+
+func (al *AppLogger) Warn(format string, v ...interface{}) {
+	if !al.Enabled(LevelWarning) {
+		return
+	}
+	al.guardFormat(format, v)
+	al.writeMsg(LevelWarning, nil, format, v...)
 }
 
 func (al *AppLogger) Debug(format string, v ...interface{}) {
-	if LevelDebug > al.level {
+	if !al.Enabled(LevelDebug) {
 		return
 	}
-	al.writeMsg(LevelDebug, format, v...)
+	al.guardFormat(format, v)
+	al.writeMsg(LevelDebug, nil, format, v...)
 }
 
 
 func (al *AppLogger) Error(format string, v ...interface{}) {
-	if LevelError > al.level {
+	if !al.Enabled(LevelError) {
+		return
+	}
+	al.guardFormat(format, v)
+	al.writeMsg(LevelError, nil, format, v...)
+}
+
+// Write 实现 io.Writer，可以用 log.SetOutput(al) 接管标准库 log 的输出；
+// p 不受级别限制，一定会写出，并且不带级别标记
+func (al *AppLogger) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	// 标准库 log 会在结尾加 '\n'，writeln 会再加一个
+	msg := strings.TrimSuffix(string(p), "\n")
+	if err := al.writeMsg(levelLoggerImpl, nil, msg); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// LogSync 以 level 级别写log，异步模式下也会等到log写到所有 Logger 并刷新之后才返回，返回第一个写入失败的错误
+func (al *AppLogger) LogSync(level int, format string, v ...interface{}) error {
+	if !al.Enabled(level) {
+		return nil
+	}
+	return al.writeMsgWait(level, nil, format, v...)
+}
+
+// Fatal 不受级别限制，以 Error 级别写log，刷新所有 Logger 后以 SetFatalExitCode 设置的退出码退出进程
+func (al *AppLogger) Fatal(format string, v ...interface{}) {
+	al.writeMsg(LevelError, nil, format, v...)
+	al.lock.Lock()
+	code := al.fatalExitCode
+	al.lock.Unlock()
+	al.exit(code)
+}
+
+// FatalCode 同 Fatal，但本次使用 code 作为退出码
+func (al *AppLogger) FatalCode(code int, format string, v ...interface{}) {
+	al.writeMsg(LevelError, nil, format, v...)
+	al.exit(code)
+}
+
+// SetFatalExitCode 设置 Fatal 的退出码，默认为 1
+func (al *AppLogger) SetFatalExitCode(code int) {
+	al.ensureInit()
+	al.lock.Lock()
+	al.fatalExitCode = code
+	al.lock.Unlock()
+}
+
+// 先刷新所有 Logger 再退出，保证 Fatal 的 log 不会丢失
+func (al *AppLogger) exit(code int) {
+	al.Flush()
+	exitFunc(code)
+}
+
+// WithTemporaryLevel 在执行 f 期间把级别临时设置为 l，f 返回（包括 panic）后恢复原来的级别
+func (al *AppLogger) WithTemporaryLevel(l int, f func()) {
+	al.ensureInit()
+	old := atomic.SwapInt32(&al.levelMask, levelsUpTo(l))
+	defer atomic.StoreInt32(&al.levelMask, old)
+	f()
+}
+
+// SetLevelStyle 设置级别在行首的写法：LevelStyleShort、LevelStyleLong 或 LevelStyleNumeric
+func (al *AppLogger) SetLevelStyle(style int) error {
+	if style < LevelStyleShort || style > LevelStyleNumeric {
+		return fmt.Errorf("logs: unknown level style %d", style)
+	}
+	atomic.StoreInt32(&al.levelStyle, int32(style))
+	return nil
+}
+
+// SetTimeFunc 设置修改每条log时间的函数，在按 SetTimePrecision 截断和格式化之前调用，
+// 可以用来修正时钟偏差、取整或者给回放的数据加上固定的偏移；fn 为 nil 时取消
+func (al *AppLogger) SetTimeFunc(fn func(time.Time) time.Time) {
+	al.timeFunc.Store(timeFuncHolder{fn})
+}
+
+type timeFuncHolder struct {
+	fn func(time.Time) time.Time
+}
+
+// stamp 对 t 依次应用 SetTimeFunc 设置的函数和精度 precision
+func (al *AppLogger) stamp(t time.Time, precision time.Duration) time.Time {
+	if h, _ := al.timeFunc.Load().(timeFuncHolder); h.fn != nil {
+		t = h.fn(t)
+	}
+	if precision > 0 {
+		t = t.Truncate(precision)
+	}
+	return t
+}
+
+// SetTimePrecision 设置时间的精度，可以是 time.Second、time.Millisecond、time.Microsecond 等：
+// 时间先按 p 截断，时间头的小数部分也按 p 的位数输出，不管 Logger 配置的时间格式原来是几位；p <= 0 时恢复默认
+func (al *AppLogger) SetTimePrecision(p time.Duration) {
+	atomic.StoreInt64(&al.timePrecision, int64(p))
+}
+
+// EnableFuncCallDepth 为 true 时在消息前面加上调用位置 [file:line]
+func (al *AppLogger) EnableFuncCallDepth(b bool) {
+	al.lock.Lock()
+	al.enableFuncCallDepth = b
+	al.lock.Unlock()
+}
+
+// EnableFuncName 为 true 时调用位置里再加上函数名，如 [main.go:12 main.(*server).handle]，
+// 只在 EnableFuncCallDepth 开启时有效
+func (al *AppLogger) EnableFuncName(b bool) {
+	al.lock.Lock()
+	al.enableFuncName = b
+	al.lock.Unlock()
+}
+
+// funcName 返回 pc 所在函数的 包名.函数名，去掉包的路径
+func funcName(pc uintptr) string {
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return "???"
+	}
+	name := fn.Name()
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// Enabled 返回 level 级别的log当前是否会被写出，可以在构造开销大的参数之前先判断；
+// 设置了模块级别时，只要有一个模块会写出就返回 true
+func (al *AppLogger) Enabled(level int) bool {
+	al.ensureInit()
+	if al.levelEnabled(level) {
+		return true
+	}
+	max, ok := al.modules.maxLevel()
+	return ok && level <= max
+}
+
+// IsErrorEnabled 同 Enabled(LevelError)
+func (al *AppLogger) IsErrorEnabled() bool {
+	return al.Enabled(LevelError)
+}
+
+// IsWarnEnabled 同 Enabled(LevelWarning)
+func (al *AppLogger) IsWarnEnabled() bool {
+	return al.Enabled(LevelWarning)
+}
+
+// IsInfoEnabled 同 Enabled(LevelInfo)
+func (al *AppLogger) IsInfoEnabled() bool {
+	return al.Enabled(LevelInfo)
+}
+
+// IsDebugEnabled 同 Enabled(LevelDebug)
+func (al *AppLogger) IsDebugEnabled() bool {
+	return al.Enabled(LevelDebug)
+}
+
+// SetLevel 设置级别，level 及更严重级别的log会被写出，同时覆盖 EnableLevel 的设置
+func (al *AppLogger) SetLevel(level int) {
+	al.ensureInit()
+	atomic.StoreInt32(&al.levelMask, levelsUpTo(level))
+}
+
+// EnableLevel 单独打开或关闭 level 级别的log，不影响其他级别，如只保留 Error 和 Debug
+func (al *AppLogger) EnableLevel(level int, on bool) {
+	if level < LevelError || level > LevelDebug {
+		return
+	}
+	al.ensureInit()
+	for {
+		old := atomic.LoadInt32(&al.levelMask)
+		mask := old &^ (1 << uint(level))
+		if on {
+			mask = old | 1<<uint(level)
+		}
+		if atomic.CompareAndSwapInt32(&al.levelMask, old, mask) {
+			return
+		}
+	}
+}
+
+// levelsUpTo 返回 level 及更严重级别的掩码
+func levelsUpTo(level int) int32 {
+	if level < LevelError {
+		return 0
+	}
+	if level > LevelDebug {
+		level = LevelDebug
+	}
+	return 1<<uint(level+1) - 1
+}
+
+// levelEnabled 并发安全地判断 level 是否打开，不带级别的log总是打开的
+func (al *AppLogger) levelEnabled(level int) bool {
+	if level < LevelError {
+		return true
+	}
+	return level <= LevelDebug && atomic.LoadInt32(&al.levelMask)&(1<<uint(level)) != 0
+}
+
+// 并发安全地读取当前打开的最低级别（最不严重），没有打开任何级别时为 -1
+func (al *AppLogger) getLevel() int {
+	mask := atomic.LoadInt32(&al.levelMask)
+	for level := LevelDebug; level >= LevelError; level-- {
+		if mask&(1<<uint(level)) != 0 {
+			return level
+		}
+	}
+	return -1
+}
+
+// PushPrefix 压入一个前缀，已压入的前缀按顺序以空格连接后放在消息前面，如 "[svc] [handler] msg"；
+// 返回的函数把前缀栈恢复到压入之前的状态，只有第一次调用有效
+func (al *AppLogger) PushPrefix(p string) func() {
+	al.lock.Lock()
+	n := len(al.prefixStack)
+	al.prefixStack = append(al.prefixStack[:n:n], p)
+	al.lock.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			al.lock.Lock()
+			if len(al.prefixStack) > n {
+				al.prefixStack = al.prefixStack[:n:n]
+			}
+			al.lock.Unlock()
+		})
+	}
+}
+
+// 放在消息前面的上下文：host/pid 和前缀栈连接后的字符串
+func (al *AppLogger) contextPrefix() string {
+	al.lock.Lock()
+	defer al.lock.Unlock()
+	parts := al.prefixStack
+	if len(al.hostPID) > 0 {
+		parts = append([]string{al.fieldFormat.format(al.hostPID)}, parts...)
+	}
+	return al.fieldFormat.join(parts)
+}
+
+// EnableHostPID 为 true 时在每条消息前加上 "host=<主机名> pid=<进程号>"，主机名和进程号只在开启时获取一次
+func (al *AppLogger) EnableHostPID(b bool) {
+	var hostPID []Field
+	if b {
+		host, err := os.Hostname()
+		if err != nil {
+			host = "unknown"
+		}
+		hostPID = []Field{String("host", host), Int("pid", os.Getpid())}
+	}
+	al.lock.Lock()
+	al.hostPID = hostPID
+	al.lock.Unlock()
+}
+
+// Timer 记录开始时间，返回的函数被调用时以 Debug 级别写出 "name took <耗时>"，只有第一次调用有效
+func (al *AppLogger) Timer(name string) func() {
+	start := time.Now()
+	var called int32
+	return func() {
+		// 不用 sync.Once，调用位置要算到调用这个函数的地方
+		if !atomic.CompareAndSwapInt32(&called, 0, 1) || !al.Enabled(LevelDebug) {
+			return
+		}
+		al.output(0, true, LevelDebug, nil, "%s took %v", []interface{}{name, time.Since(start)})
+	}
+}
+
+// Recover 用于 defer al.Recover()，捕获 panic 后以 Error 级别记录 panic 的值和调用栈，刷新所有 Logger 后重新 panic
+// 开启了 EnableFuncCallDepth 时调用位置是引发 panic 的地方
+func (al *AppLogger) Recover() {
+	if p := recover(); p != nil {
+		al.ensureInit()
+		var r Record
+		if al.enableFuncCallDepth {
+			frame := panicFrame()
+			_, r.File = path.Split(frame.File)
+			r.Line = frame.Line
+			if al.enableFuncName && frame.Function != "" {
+				r.Func = funcName(frame.PC)
+			}
+		}
+		al.fillRecord(&r, LevelError, nil, "panic: %v\n%s", []interface{}{p, debug.Stack()})
+		al.dispatch(r, nil)
+		al.Flush()
+		panic(p)
+	}
+}
+
+// panicFrame 在正在处理 panic 的 defer 函数里调用，返回引发 panic 的栈帧：跳过 panic 本身和 runtime 里的栈帧
+func panicFrame() runtime.Frame {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	panicking := false
+	for {
+		frame, more := frames.Next()
+		if frame.Function == "runtime.gopanic" {
+			panicking = true
+		} else if panicking && !strings.HasPrefix(frame.Function, "runtime.") {
+			return frame
+		}
+		if !more {
+			return runtime.Frame{File: "???"}
+		}
+	}
+}
+
+// ErrorSeverity 可以自己决定以什么级别记录的错误，如参数校验失败返回 LevelWarning，见 LogErr
+type ErrorSeverity interface {
+	Severity() int
+}
+
+// LogErr 以 err 决定的级别写log并带上 error 字段：err 的链上（errors.As）有 ErrorSeverity 时使用它返回的级别，
+// 否则或者级别未知时使用 LevelError；err 为 nil 时什么都不做
+func (al *AppLogger) LogErr(err error, format string, v ...interface{}) {
+	if err == nil {
+		return
+	}
+	level := LevelError
+	var s ErrorSeverity
+	if errors.As(err, &s) && validLevel(s.Severity()) {
+		level = s.Severity()
+	}
+	if !al.Enabled(level) {
 		return
 	}
-	al.writeMsg(LevelError, format, v...)
+	al.writeMsg(level, []Field{Err(err)}, format, v...)
+}
+
+// LogRecovered 以 Error 级别记录 recover() 得到的 r 和调用栈，不重新 panic，r 为 nil 时什么都不做；
+// 用法如 defer func() { al.LogRecovered(recover()) }()。panic_type 字段是 r 的类型，如 "*errors.errorString"、"string"，
+// 结构体的值带着字段名写出
+func (al *AppLogger) LogRecovered(r interface{}) {
+	if r == nil {
+		return
+	}
+	fields := []Field{String("panic_type", fmt.Sprintf("%T", r))}
+	al.writeMsg(LevelError, fields, "panic: %+v\n%s", r, strings.TrimSuffix(string(debug.Stack()), "\n"))
 }
 
 
@@ -350,7 +1768,14 @@ func (al *AppLogger) Error(format string, v ...interface{}) {
 
 type logWriter struct {
 	sync.Mutex
-	writer io.Writer
+	writer    io.Writer
+	noNewline bool // 为 true 时不在每行末尾追加 '\n'，由下游自己分帧
+	timeFormat string // 时间头的格式，为空时使用 layout
+	lengthPrefix bool // 为 true 时每行前面加4字节大端序的长度而不是在结尾加 '\n'，消息里可以有换行
+	wrapColumn int    // 大于 0 时每行超过这么多个字符就折行，见 wrapLine
+	wrapIndent string // 折行后续行前面的缩进
+	zones      timeZones
+	fileLock   bool // 为 true 且 writer 是 *os.File 时，每次写入都持有文件的 flock 排他锁，见 fileWriter.FileLock
 }
 
 func newLogWriter(wr io.Writer) *logWriter {
@@ -358,17 +1783,170 @@ func newLogWriter(wr io.Writer) *logWriter {
 }
 
 func (lg *logWriter) writeln(when time.Time, msg string) (int, error) {
+	return lg.writeLine(when, 0, msg, nil)
+}
+
+// writeLine 同 writeln，precision 大于 0 时时间头的小数部分按 precision 的精度输出；
+// brush 不为 nil 时用它包住整行（时间头到消息结尾，不含换行）
+func (lg *logWriter) writeLine(when time.Time, precision time.Duration, msg string, b brush) (int, error) {
 	lg.Lock()
-	h := formatTimeHeader(when)
-	n, err := lg.writer.Write(append(append(h, msg...), '\n'))
+	line := append(formatTimeHeader(when, withPrecision(lg.timeFormat, precision), lg.zones), msg...)
+	if b != nil {
+		line = []byte(b(string(line)))
+	}
+	if lg.wrapColumn > 0 {
+		line = wrapLine(line, lg.wrapColumn, lg.wrapIndent)
+	}
+	n, err := lg.write(lg.frame(line))
 	lg.Unlock()
 	return n, err
 }
 
+// write 把 p 完整写到 writer，短写时继续写剩下的部分，直到写完或者出错；
+// writer 没有报错却一个字节也没写时返回 io.ErrShortWrite。调用时需持有锁
+func (lg *logWriter) write(p []byte) (int, error) {
+	if f, ok := lg.writer.(*os.File); ok && lg.fileLock {
+		// 拿不到锁时照样写出，宁可和其他进程交错也不丢log
+		if lockFile(f) == nil {
+			defer unlockFile(f)
+		}
+	}
+	written := 0
+	for written < len(p) {
+		n, err := lg.writer.Write(p[written:])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		if n == 0 {
+			return written, io.ErrShortWrite
+		}
+	}
+	return written, nil
+}
+
+// wrapLine 每 column 个可见字符插入一个换行和 indent，ANSI 转义序列不计入长度，也不会被拆开；
+// 消息里原有的换行重新开始计数
+func wrapLine(line []byte, column int, indent string) []byte {
+	if utf8.RuneCount(line) <= column {
+		return line
+	}
+	wrapped := make([]byte, 0, len(line)+len(line)/column*(len(indent)+1))
+	visible := 0
+	for i := 0; i < len(line); {
+		if line[i] == '\033' && i+1 < len(line) && line[i+1] == '[' {
+			j := i + 2
+			for j < len(line) && (line[j] < 0x40 || line[j] > 0x7e) {
+				j++
+			}
+			if j < len(line) {
+				j++
+			}
+			wrapped = append(wrapped, line[i:j]...)
+			i = j
+			continue
+		}
+		if line[i] == '\n' {
+			wrapped = append(wrapped, '\n')
+			visible = 0
+			i++
+			continue
+		}
+		if visible >= column {
+			wrapped = append(wrapped, '\n')
+			wrapped = append(wrapped, indent...)
+			visible = utf8.RuneCountInString(indent)
+		}
+		_, size := utf8.DecodeRune(line[i:])
+		wrapped = append(wrapped, line[i:i+size]...)
+		visible++
+		i += size
+	}
+	return wrapped
+}
+
+// frame 给一行加上分帧：默认在结尾追加 '\n'，lengthPrefix 时在前面加4字节大端序的长度
+func (lg *logWriter) frame(line []byte) []byte {
+	if lg.lengthPrefix {
+		framed := make([]byte, 4, 4+len(line))
+		binary.BigEndian.PutUint32(framed, uint32(len(line)))
+		return append(framed, line...)
+	}
+	if !lg.noNewline {
+		line = append(line, '\n')
+	}
+	return line
+}
+
+// withPrecision 把时间格式 timeFormat 里秒后面的小数部分换成 precision 对应的位数，timeFormat 为空时使用 layout
+func withPrecision(timeFormat string, precision time.Duration) string {
+	if precision <= 0 {
+		return timeFormat
+	}
+	if timeFormat == "" {
+		timeFormat = layout
+	}
+	i := strings.Index(timeFormat, "05")
+	if i < 0 {
+		return timeFormat
+	}
+	i += 2
+	j := i
+	if j < len(timeFormat) && (timeFormat[j] == '.' || timeFormat[j] == ',') {
+		j++
+		for j < len(timeFormat) && (timeFormat[j] == '0' || timeFormat[j] == '9') {
+			j++
+		}
+	}
+	digits := 0
+	for d := time.Second; d > precision && digits < 9; d /= 10 {
+		digits++
+	}
+	fraction := ""
+	if digits > 0 {
+		fraction = "." + strings.Repeat("0", digits)
+	}
+	return timeFormat[:i] + fraction + timeFormat[j:]
+}
 
-func formatTimeHeader(when time.Time) ([]byte) {
-	whenS := when.Format(layout) + "  "
-	whenB := []byte(whenS)
+func formatTimeHeader(when time.Time, timeFormat string, zones timeZones) ([]byte) {
+	if timeFormat == "" {
+		timeFormat = layout
+	}
+	if zones.primary != nil {
+		when = when.In(zones.primary)
+	}
+	whenS := when.Format(timeFormat)
+	if zones.secondary != nil {
+		whenS += " (" + when.In(zones.secondary).Format(secondTimeLayout) + ")"
+	}
+	whenB := []byte(whenS + "  ")
 	return whenB 
 }
 
+// 第二时区的时间格式
+const secondTimeLayout = "15:04 MST"
+
+// timeZones 时间头使用的时区，primary 为 nil 时使用时间本身的时区，secondary 不为 nil 时在后面括号里再写一个时区的时间
+type timeZones struct {
+	primary   *time.Location
+	secondary *time.Location
+}
+
+// loadTimeZones 按 IANA 名字（如 "UTC"、"America/Los_Angeles"）加载时区，名字为空表示不设置
+func loadTimeZones(primary, secondary string) (timeZones, error) {
+	var zones timeZones
+	var err error
+	if primary != "" {
+		if zones.primary, err = time.LoadLocation(primary); err != nil {
+			return zones, fmt.Errorf("logs: invalid time_zone %q: %v", primary, err)
+		}
+	}
+	if secondary != "" {
+		if zones.secondary, err = time.LoadLocation(secondary); err != nil {
+			return zones, fmt.Errorf("logs: invalid second_time_zone %q: %v", secondary, err)
+		}
+	}
+	return zones, nil
+}
+
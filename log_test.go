@@ -0,0 +1,1140 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stuckLogger 的 Flush 一直阻塞到 release 被关闭
+type stuckLogger struct {
+	captureLogger
+	release chan struct{}
+}
+
+func (s *stuckLogger) Flush() {
+	<-s.release
+}
+
+func TestCloseTimeout(t *testing.T) {
+	al, c := newTestLogger(t)
+	stuck := &stuckLogger{release: make(chan struct{})}
+	defer close(stuck.release)
+	al.AddAdapter("stuck", stuck)
+	al.SetCloseTimeout(50 * time.Millisecond)
+	var mu sync.Mutex
+	var reported []string
+	al.SetErrorHandler(func(name string, err error) {
+		mu.Lock()
+		reported = append(reported, name+": "+err.Error())
+		mu.Unlock()
+	})
+
+	within(t, 2*time.Second, "Close", al.Close)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) != 1 || !strings.Contains(reported[0], "stuck: logs: flush timed out") {
+		t.Errorf("reported %q", reported)
+	}
+	// 其他 Logger 照常刷新和销毁
+	if c.flushed == 0 || c.destroyed != 1 {
+		t.Errorf("capture flushed %d, destroyed %d", c.flushed, c.destroyed)
+	}
+}
+
+func TestFatalExitCode(t *testing.T) {
+	codes := fakeExit(t)
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.EnableLevel(LevelError, false)
+
+	al.Fatal("default")
+	al.SetFatalExitCode(3)
+	al.Fatal("configured")
+	al.FatalCode(70, "explicit")
+
+	if got := *codes; len(got) != 3 || got[0] != 1 || got[1] != 3 || got[2] != 70 {
+		t.Errorf("exit codes %v", got)
+	}
+	// Fatal 不受级别限制，以 Error 级别写出
+	records := c.all()
+	if len(records) != 3 || records[0].Level != LevelError {
+		t.Errorf("got %q", c.lines())
+	}
+}
+
+// SetFatalExitCode 可以和 Fatal 同时调用，用 -race 检查
+func TestFatalExitCodeConcurrent(t *testing.T) {
+	var exits int32
+	old := exitFunc
+	exitFunc = func(code int) { atomic.AddInt32(&exits, 1) }
+	defer func() { exitFunc = old }()
+	al, _ := newTestLogger(t)
+	defer al.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			al.SetFatalExitCode(i)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		al.Fatal("fatal %d", i)
+	}
+	<-done
+	if n := atomic.LoadInt32(&exits); n != 100 {
+		t.Errorf("exit called %d times", n)
+	}
+}
+
+// Fatal 通过 exitFunc 退出，退出之前异步 channel 里的log已经写出
+func TestFatalFlushesBeforeExit(t *testing.T) {
+	al, c := newTestLogger(t)
+	al.Async()
+	defer al.Close()
+	var written []string
+	old := exitFunc
+	exitFunc = func(code int) { written = c.lines() }
+	defer func() { exitFunc = old }()
+
+	for i := 0; i < 100; i++ {
+		al.Info("before %d", i)
+	}
+	al.Fatal("fatal")
+	if len(written) != 101 || !strings.HasSuffix(written[100], "fatal") {
+		t.Errorf("%d lines written before exit", len(written))
+	}
+}
+
+func TestWithTemporaryLevel(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.SetLevel(LevelInfo)
+
+	al.WithTemporaryLevel(LevelDebug, func() {
+		al.Debug("inside")
+	})
+	al.Debug("after")
+	func() {
+		defer func() { recover() }()
+		al.WithTemporaryLevel(LevelDebug, func() { panic("boom") })
+	}()
+	al.Debug("after panic")
+
+	if lines := c.lines(); len(lines) != 1 || !strings.HasSuffix(lines[0], "inside") {
+		t.Errorf("got %q", lines)
+	}
+	if al.Enabled(LevelDebug) || !al.Enabled(LevelInfo) {
+		t.Error("level not restored")
+	}
+}
+
+func TestLastError(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.SetErrorHandler(func(string, error) {})
+	if _, name, err := al.LastError(); name != "" || err != nil {
+		t.Fatalf("LastError() = %q, %v before any failure", name, err)
+	}
+
+	c.setErr(errors.New("broken pipe"))
+	start := time.Now()
+	al.Info("fails")
+	when, name, err := al.LastError()
+	if name != "capture" || err == nil || err.Error() != "broken pipe" || when.Before(start) {
+		t.Errorf("LastError() = %v, %q, %v", when, name, err)
+	}
+
+	// 同一个 Logger 写入成功后清除
+	c.setErr(nil)
+	al.Info("works")
+	if _, name, err := al.LastError(); name != "" || err != nil {
+		t.Errorf("LastError() = %q, %v after recovery", name, err)
+	}
+}
+
+func TestPushPrefix(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+
+	popSvc := al.PushPrefix("[svc]")
+	popHandler := al.PushPrefix("[handler]")
+	al.Info("nested")
+	popHandler()
+	popHandler()
+	al.Info("outer")
+	popSvc()
+	al.Info("none")
+
+	records := c.all()
+	want := []string{"[svc] [handler]", "[svc]", ""}
+	for i, w := range want {
+		if records[i].Prefix != w {
+			t.Errorf("record %d prefix %q, want %q", i, records[i].Prefix, w)
+		}
+	}
+	if lines := c.lines(); !strings.HasSuffix(lines[0], "[svc] [handler] nested") {
+		t.Errorf("got %q", lines[0])
+	}
+}
+
+func TestDrainResume(t *testing.T) {
+	al, c := newTestLogger(t)
+	al.Async()
+	defer al.Close()
+	for i := 0; i < 100; i++ {
+		al.Info("queued %d", i)
+	}
+
+	al.Drain()
+	if n := len(c.all()); n != 100 {
+		t.Fatalf("%d records after Drain", n)
+	}
+	if c.flushed == 0 || c.destroyed != 0 {
+		t.Errorf("flushed %d, destroyed %d", c.flushed, c.destroyed)
+	}
+	logged := make(chan struct{})
+	go func() {
+		al.Info("after drain")
+		close(logged)
+	}()
+	select {
+	case <-logged:
+		t.Fatal("logging did not block while drained")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	al.Resume()
+	within(t, time.Second, "blocked Info", func() { <-logged })
+	al.Flush()
+	if lines := c.lines(); len(lines) != 101 || !strings.HasSuffix(lines[100], "after drain") {
+		t.Errorf("%d lines after Resume", len(lines))
+	}
+}
+
+// captureStderr 返回执行 f 期间写到 os.Stderr 的内容
+func captureStderr(t *testing.T, f func()) string {
+	t.Helper()
+	return captureFile(t, &os.Stderr, f)
+}
+
+// captureFile 执行 f 期间把 *file 换成管道，返回写进去的内容
+func captureFile(t *testing.T, file **os.File, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := *file
+	*file = w
+	out := make(chan string)
+	go func() {
+		b, _ := ioutil.ReadAll(r)
+		out <- string(b)
+	}()
+	f()
+	*file = old
+	w.Close()
+	return <-out
+}
+
+func TestErrorReportThrottle(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.SetErrorReportInterval(100 * time.Millisecond)
+	c.setErr(errors.New("disk full"))
+
+	out := captureStderr(t, func() {
+		for i := 0; i < 5; i++ {
+			al.Info("fails")
+		}
+		time.Sleep(150 * time.Millisecond)
+		al.Info("fails")
+	})
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %q", out)
+	}
+	if lines[0] != "logs: adapter:capture,error:disk full" {
+		t.Errorf("first report %q", lines[0])
+	}
+	if lines[1] != "logs: adapter:capture,error:disk full (suppressed 4 similar errors)" {
+		t.Errorf("second report %q", lines[1])
+	}
+}
+
+// slowLogger 每次写入等待 delay，并记录同时在写的最大数量
+type slowLogger struct {
+	captureLogger
+	delay        time.Duration
+	active, peak int32
+}
+
+func (s *slowLogger) WriteRecord(r *Record) error {
+	n := atomic.AddInt32(&s.active, 1)
+	for {
+		peak := atomic.LoadInt32(&s.peak)
+		if n <= peak || atomic.CompareAndSwapInt32(&s.peak, peak, n) {
+			break
+		}
+	}
+	time.Sleep(s.delay)
+	atomic.AddInt32(&s.active, -1)
+	return s.captureLogger.WriteRecord(r)
+}
+
+func TestAsyncWorkers(t *testing.T) {
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	slow := &slowLogger{delay: 2 * time.Millisecond}
+	al.AddAdapter("slow", slow)
+	al.AsyncWorkers(4).Async(100)
+	for i := 0; i < 40; i++ {
+		al.Info("msg %d", i)
+	}
+	al.Flush()
+	if n := len(slow.all()); n != 40 {
+		t.Errorf("%d records after Flush", n)
+	}
+	if peak := atomic.LoadInt32(&slow.peak); peak < 2 {
+		t.Errorf("at most %d concurrent writes with 4 workers", peak)
+	}
+	al.Close()
+	if slow.destroyed != 1 {
+		t.Errorf("destroyed %d times", slow.destroyed)
+	}
+}
+
+func BenchmarkAsyncWorkers(b *testing.B) {
+	for _, workers := range []int{1, 4} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			al := NewAppLogger()
+			al.RemoveLogger(AdapterConsole)
+			al.AddWriter("discard", ioutil.Discard, LevelDebug)
+			al.AsyncWorkers(workers).Async(1024)
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					al.Info("request %d handled", 42)
+				}
+			})
+			al.Flush()
+			b.StopTimer()
+			al.Close()
+		})
+	}
+}
+
+func TestEnableHostPID(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	host, _ := os.Hostname()
+	al.EnableHostPID(true)
+	al.Info("with host")
+	al.EnableHostPID(false)
+	al.Info("without host")
+
+	lines := c.lines()
+	want := fmt.Sprintf("host=%s pid=%d with host", host, os.Getpid())
+	if !strings.HasSuffix(lines[0], want) {
+		t.Errorf("got %q, want suffix %q", lines[0], want)
+	}
+	if strings.Contains(lines[1], "pid=") {
+		t.Errorf("got %q after disabling", lines[1])
+	}
+}
+
+// gateLogger 的每次写入都等 gate 里的一个值或者 gate 被关闭
+type gateLogger struct {
+	captureLogger
+	gate chan struct{}
+}
+
+func (g *gateLogger) WriteRecord(r *Record) error {
+	<-g.gate
+	return g.captureLogger.WriteRecord(r)
+}
+
+func TestResizeAsyncBuffer(t *testing.T) {
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	g := &gateLogger{gate: make(chan struct{})}
+	al.AddAdapter("gate", g)
+	al.Async(10)
+	defer al.Close()
+
+	for i := 0; i < 5; i++ {
+		al.Info("msg %d", i)
+	}
+	// 第一条被取走后卡在写入中，其余的留在 channel 里
+	g.gate <- struct{}{}
+	if err := al.ResizeAsyncBuffer(2); err == nil {
+		t.Error("shrank the buffer below the queued messages")
+	}
+	if err := al.ResizeAsyncBuffer(0); err == nil {
+		t.Error("accepted length 0")
+	}
+	// 调整长度要等正在写的log写完
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(g.gate)
+	}()
+	within(t, time.Second, "ResizeAsyncBuffer", func() {
+		if err := al.ResizeAsyncBuffer(20); err != nil {
+			t.Error(err)
+		}
+	})
+	if n := cap(al.msgChan); n != 20 {
+		t.Errorf("cap = %d", n)
+	}
+	al.Flush()
+	lines := g.lines()
+	if len(lines) != 5 {
+		t.Fatalf("got %q", lines)
+	}
+	for i, l := range lines {
+		if !strings.HasSuffix(l, fmt.Sprintf("msg %d", i)) {
+			t.Errorf("line %d = %q", i, l)
+		}
+	}
+}
+
+func TestEffectiveLevel(t *testing.T) {
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	defer al.Close()
+	var a, b bytes.Buffer
+	al.AddWriter("a", &a, LevelWarning)
+	al.AddWriter("b", &b, LevelError)
+
+	if got := al.EffectiveLevel(); got != LevelWarning {
+		t.Errorf("EffectiveLevel() = %d, want the highest adapter level", got)
+	}
+	if err := al.SetAdapterLevel("b", LevelDebug); err != nil {
+		t.Fatal(err)
+	}
+	al.SetLevel(LevelInfo)
+	if got := al.EffectiveLevel(); got != LevelInfo {
+		t.Errorf("EffectiveLevel() = %d, want the AppLogger level", got)
+	}
+	al.Debug("hidden")
+	al.Info("to b")
+	if a.Len() != 0 || !strings.Contains(b.String(), "to b") {
+		t.Errorf("a got %q, b got %q", a.String(), b.String())
+	}
+
+	// 没有实现 LevelWriter 的 Logger
+	al.AddAdapter("capture", &captureLogger{})
+	if err := al.SetAdapterLevel("capture", LevelError); err == nil {
+		t.Error("set the level of a Logger without LevelWriter")
+	}
+	if err := al.SetAdapterLevel("missing", LevelError); err == nil {
+		t.Error("set the level of a missing Logger")
+	}
+}
+
+// 异步 worker 写log的同时修改 Logger 的级别，用 -race 检查
+func TestSetAdapterLevelAsync(t *testing.T) {
+	inTempDir(t)
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	al.AddWriter("writer", ioutil.Discard, LevelDebug)
+	if err := al.AddLogger(AdapterFile, `{"filename":"app.log"}`); err != nil {
+		t.Fatal(err)
+	}
+	con := NewConsole().(*consoleWriter)
+	con.lg = newLogWriter(ioutil.Discard)
+	al.AddAdapter(AdapterConsole, con)
+	al.Async()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			al.Info("msg %d", i)
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		for _, name := range []string{"writer", AdapterFile, AdapterConsole} {
+			if err := al.SetAdapterLevel(name, i%(LevelDebug+1)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	<-done
+	al.SetAdapterLevel(AdapterFile, LevelWarning)
+	al.Info("filtered")
+	al.Warn("written")
+	al.Close()
+	if got := readFile(t, "app.log"); strings.Contains(got, "filtered") || !strings.Contains(got, "written") {
+		t.Errorf("file got %q", got)
+	}
+}
+
+func TestFallback(t *testing.T) {
+	al, a := newTestLogger(t)
+	defer al.Close()
+	b := &captureLogger{}
+	al.AddAdapter("b", b)
+	al.SetErrorHandler(func(string, error) {})
+	var fallback bytes.Buffer
+	al.SetFallback(&fallback)
+
+	a.setErr(errors.New("a down"))
+	al.Info("one adapter left")
+	if fallback.Len() != 0 || al.FallbackCount() != 0 {
+		t.Errorf("fallback used while b works: %q", fallback.String())
+	}
+
+	b.setErr(errors.New("b down"))
+	al.Info("all down")
+	if al.FallbackCount() != 1 || !strings.Contains(fallback.String(), fallbackMarker+" [I]  all down") {
+		t.Errorf("fallback got %d: %q", al.FallbackCount(), fallback.String())
+	}
+
+	al.SetFallback(nil)
+	al.Info("no fallback")
+	if al.FallbackCount() != 1 {
+		t.Errorf("FallbackCount() = %d after removing the fallback", al.FallbackCount())
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	al, _ := newTestLogger(t)
+	defer al.Close()
+	al.SetLevel(LevelWarning)
+	if !al.IsErrorEnabled() || !al.IsWarnEnabled() || al.IsInfoEnabled() || al.IsDebugEnabled() {
+		t.Error("wrong levels after SetLevel(LevelWarning)")
+	}
+	if al.Enabled(LevelDebug + 1) {
+		t.Error("unknown level enabled")
+	}
+	// 有模块打开了 Debug 时返回 true
+	al.SetModuleLevel("example.com/verbose", LevelDebug)
+	if !al.Enabled(LevelDebug) {
+		t.Error("Debug not enabled with a Debug module level")
+	}
+	al.ClearModuleLevel("example.com/verbose")
+	if al.Enabled(LevelDebug) {
+		t.Error("Debug enabled after clearing the module level")
+	}
+}
+
+func TestLogSync(t *testing.T) {
+	al, c := newTestLogger(t)
+	al.Async()
+	defer al.Close()
+	al.SetErrorHandler(func(string, error) {})
+
+	for i := 0; i < 50; i++ {
+		al.Info("queued %d", i)
+	}
+	if err := al.LogSync(LevelInfo, "confirmed %d", 1); err != nil {
+		t.Fatal(err)
+	}
+	// 返回时这条和之前的log都已经写出并刷新
+	lines := c.lines()
+	if len(lines) != 51 || !strings.HasSuffix(lines[50], "confirmed 1") || c.flushed == 0 {
+		t.Errorf("%d lines, flushed %d", len(lines), c.flushed)
+	}
+
+	c.setErr(errors.New("disk full"))
+	if err := al.LogSync(LevelError, "lost"); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("LogSync() = %v", err)
+	}
+	al.SetLevel(LevelError)
+	if err := al.LogSync(LevelInfo, "disabled"); err != nil {
+		t.Errorf("LogSync() = %v below the level", err)
+	}
+}
+
+func TestSetLevelStyle(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.Warn("short")
+	al.SetLevelStyle(LevelStyleLong)
+	al.Warn("long")
+	al.SetLevelStyle(LevelStyleNumeric)
+	al.Warn("numeric")
+	if err := al.SetLevelStyle(LevelStyleNumeric + 1); err == nil {
+		t.Error("accepted an unknown style")
+	}
+
+	want := []string{"[W]  short", "WARN  long", "[1]  numeric"}
+	lines := c.lines()
+	for i, w := range want {
+		if lines[i] != w {
+			t.Errorf("line %d = %q, want %q", i, lines[i], w)
+		}
+	}
+}
+
+// Close 和其他 goroutine 写log同时发生时不会 panic，之后的log被丢弃，有 fallback 时写到 fallback
+func TestLogDuringClose(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		al, c := newTestLogger(t)
+		if async {
+			al.AsyncWorkers(2).Async(16)
+		}
+		var wg sync.WaitGroup
+		stop := make(chan struct{})
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					al.Info("worker %d", i)
+					if err := al.LogSync(LevelInfo, "sync %d", i); err != nil && err != ErrClosed {
+						t.Error(err)
+						return
+					}
+				}
+			}(i)
+		}
+		time.Sleep(10 * time.Millisecond)
+		within(t, 2*time.Second, "Close", al.Close)
+		close(stop)
+		wg.Wait()
+
+		written := len(c.all())
+		var fallback bytes.Buffer
+		al.SetFallback(&fallback)
+		al.Info("after close")
+		if err := al.LogSync(LevelInfo, "after close"); err != ErrClosed {
+			t.Errorf("async=%v: LogSync() = %v after Close", async, err)
+		}
+		if n := len(c.all()); n != written {
+			t.Errorf("async=%v: %d records written after Close", async, n-written)
+		}
+		if !strings.Contains(fallback.String(), fallbackMarker) {
+			t.Errorf("async=%v: fallback got %q", async, fallback.String())
+		}
+	}
+}
+
+func TestSetTimePrecision(t *testing.T) {
+	var buf bytes.Buffer
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	defer al.Close()
+	al.AddWriter("buf", &buf, LevelDebug)
+	when := time.Date(2024, 3, 9, 14, 5, 6, 789123456, time.UTC)
+	al.SetTimeFunc(func(time.Time) time.Time { return when })
+
+	cases := []struct {
+		precision time.Duration
+		want      string
+	}{
+		{0, "2024-03-09 14:05:06.789  "},
+		{time.Microsecond, "2024-03-09 14:05:06.789123  "},
+		{time.Nanosecond, "2024-03-09 14:05:06.789123456  "},
+		{time.Second, "2024-03-09 14:05:06  "},
+	}
+	for _, c := range cases {
+		buf.Reset()
+		al.SetTimePrecision(c.precision)
+		al.Info("x")
+		if got := buf.String(); !strings.HasPrefix(got, c.want) {
+			t.Errorf("precision %v: got %q, want prefix %q", c.precision, got, c.want)
+		}
+	}
+}
+
+func TestMuteAdapter(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	other := &captureLogger{}
+	al.AddAdapter("other", other)
+
+	if err := al.MuteAdapter("capture", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	al.Info("muted")
+	if err := al.UnmuteAdapter("capture"); err != nil {
+		t.Fatal(err)
+	}
+	al.Info("unmuted")
+	al.MuteAdapter("capture", 30*time.Millisecond)
+	al.Info("muted again")
+	time.Sleep(40 * time.Millisecond)
+	al.Info("expired")
+
+	if lines := c.lines(); len(lines) != 2 || !strings.HasSuffix(lines[0], "unmuted") || !strings.HasSuffix(lines[1], "expired") {
+		t.Errorf("muted adapter got %q", lines)
+	}
+	if n := len(other.all()); n != 4 {
+		t.Errorf("other adapter got %d records", n)
+	}
+	if al.MuteAdapter("missing", time.Second) == nil || al.UnmuteAdapter("missing") == nil {
+		t.Error("muted a missing adapter")
+	}
+}
+
+func TestEnableFuncName(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.EnableFuncCallDepth(true)
+	al.Info("file only")
+	al.EnableFuncName(true)
+	func() {
+		al.Info("with func")
+	}()
+
+	records := c.all()
+	if r := records[0]; r.Func != "" || !strings.Contains(r.String(), fmt.Sprintf("[log_test.go:%d]", r.Line)) {
+		t.Errorf("got %q, func %q", r.String(), r.Func)
+	}
+	r := records[1]
+	if r.Func != "logs.TestEnableFuncName.func1" {
+		t.Errorf("Func = %q", r.Func)
+	}
+	if want := fmt.Sprintf("[log_test.go:%d logs.TestEnableFuncName.func1]", r.Line); !strings.Contains(r.String(), want) {
+		t.Errorf("got %q, want %q", r.String(), want)
+	}
+}
+
+// Write 写入的内容不带级别标记，在所有 Logger 中的写法一致，也不受级别限制
+func TestWriteUntagged(t *testing.T) {
+	inTempDir(t)
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	defer al.Close()
+	var console, text, js bytes.Buffer
+	con := NewConsole().(*consoleWriter)
+	con.lg = newLogWriter(&console)
+	con.Level = LevelError
+	al.AddAdapter("console", con)
+	al.AddWriter("text", &text, LevelError)
+	jw := NewWriterAdapter(&js, LevelError)
+	jw.Init(`{"json":true,"level":0}`)
+	al.AddAdapter("json", jw)
+	if err := al.AddLogger(AdapterFile, `{"filename":"app.log","level":0}`); err != nil {
+		t.Fatal(err)
+	}
+	al.SetLevel(LevelError)
+
+	std := log.New(al, "", 0)
+	std.Print("from the standard library")
+	al.Close()
+
+	for name, out := range map[string]string{"console": console.String(), "text": text.String(), "file": readFile(t, "app.log")} {
+		if !strings.HasSuffix(out, "  from the standard library\n") || strings.Contains(out, "[E]") || strings.Contains(out, "\033[") {
+			t.Errorf("%s got %q", name, out)
+		}
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(js.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got["level"]; ok || got["msg"] != "from the standard library" {
+		t.Errorf("json got %s", js.String())
+	}
+}
+
+func TestEmptyMessage(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.Info("")
+	al.Log(LevelInfo, "")
+	al.Info("", "only", "args")
+	al.Log(LevelInfo, "", String("only", "fields"))
+	al.SetEmptyMsgPlaceholder("<empty>")
+	al.Info("")
+
+	lines := c.lines()
+	want := []string{"[I]  onlyargs", "[I]   only=fields", "[I]  <empty>"}
+	if len(lines) != len(want) {
+		t.Fatalf("got %q", lines)
+	}
+	for i, w := range want {
+		if lines[i] != w {
+			t.Errorf("line %d = %q, want %q", i, lines[i], w)
+		}
+	}
+}
+
+func TestSyncFrom(t *testing.T) {
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	g := &gateLogger{gate: make(chan struct{})}
+	al.AddAdapter("gate", g)
+	al.Async(100)
+	defer al.Close()
+	al.SyncFrom(LevelError)
+
+	within(t, time.Second, "Info", func() { al.Info("queued") })
+	errDone := make(chan struct{})
+	go func() {
+		al.Error("synced")
+		close(errDone)
+	}()
+	select {
+	case <-errDone:
+		t.Fatal("Error returned before it was written")
+	case <-time.After(30 * time.Millisecond):
+	}
+	close(g.gate)
+	within(t, time.Second, "Error", func() { <-errDone })
+	if lines := g.lines(); len(lines) != 2 || !strings.HasSuffix(lines[1], "synced") || g.flushed == 0 {
+		t.Errorf("got %q, flushed %d", lines, g.flushed)
+	}
+
+	al.SyncFrom(-1)
+	if atomic.LoadInt32(&al.syncFrom) != 0 {
+		t.Error("SyncFrom(-1) did not turn it off")
+	}
+}
+
+// 零值的 AppLogger 第一次写log时自动添加 console，只添加一次
+func TestZeroValueAppLogger(t *testing.T) {
+	var al AppLogger
+	out := captureFile(t, &os.Stdout, func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				al.Info("zero value %d", i)
+			}(i)
+		}
+		wg.Wait()
+		if n := len(al.outputs); n != 1 || al.outputs[0].name != AdapterConsole {
+			t.Errorf("%d Loggers attached", n)
+		}
+		al.Close()
+	})
+	for i := 0; i < 4; i++ {
+		if want := fmt.Sprintf("[I]\x1b[0m  zero value %d\n", i); !strings.Contains(out, want) {
+			t.Errorf("output %q missing %q", out, want)
+		}
+	}
+}
+
+func TestEnableLevel(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	logAll := func() {
+		al.Error("e")
+		al.Warn("w")
+		al.Info("i")
+		al.Debug("d")
+	}
+	cases := []struct {
+		setup func()
+		want  string
+	}{
+		{func() {}, "[E]  e|[W]  w|[I]  i|[D]  d"},
+		{func() { al.EnableLevel(LevelInfo, false); al.EnableLevel(LevelWarning, false) }, "[E]  e|[D]  d"},
+		{func() { al.EnableLevel(LevelError, false) }, "[D]  d"},
+		{func() { al.EnableLevel(LevelWarning, true); al.EnableLevel(LevelWarning, true) }, "[W]  w|[D]  d"},
+		{func() { al.EnableLevel(LevelDebug+1, true); al.EnableLevel(LevelError-1, false) }, "[W]  w|[D]  d"},
+		// SetLevel 恢复为累积的级别
+		{func() { al.SetLevel(LevelWarning) }, "[E]  e|[W]  w"},
+		{func() { al.SetLevel(LevelError - 1) }, ""},
+	}
+	for i, tc := range cases {
+		c.reset()
+		tc.setup()
+		logAll()
+		if got := strings.Join(c.lines(), "|"); got != tc.want {
+			t.Errorf("case %d: got %q, want %q", i, got, tc.want)
+		}
+	}
+	al.SetLevel(LevelWarning)
+	al.EnableLevel(LevelDebug, true)
+	if al.Enabled(LevelInfo) || !al.Enabled(LevelDebug) || !al.IsWarnEnabled() {
+		t.Error("Enabled does not follow the mask")
+	}
+}
+
+// panicLogger 写到 msg 为 "boom" 的log时 panic
+type panicLogger struct {
+	captureLogger
+}
+
+func (p *panicLogger) WriteRecord(r *Record) error {
+	if r.Msg == "boom" {
+		panic("buggy adapter")
+	}
+	return p.captureLogger.WriteRecord(r)
+}
+
+func TestAsyncAdapterPanic(t *testing.T) {
+	al, c := newTestLogger(t)
+	buggy := &panicLogger{}
+	al.AddAdapter("buggy", buggy)
+	errs := make(chan error, 10)
+	al.SetErrorHandler(func(name string, err error) {
+		if name == "buggy" {
+			errs <- err
+		}
+	})
+	al.Async()
+	al.Info("before")
+	al.Info("boom")
+	al.Info("after")
+	within(t, time.Second, "Close", al.Close)
+
+	if got := strings.Join(buggy.lines(), "|"); got != "[I]  before|[I]  after" {
+		t.Errorf("buggy adapter got %q", got)
+	}
+	if got := strings.Join(c.lines(), "|"); got != "[I]  before|[I]  boom|[I]  after" {
+		t.Errorf("other adapter got %q", got)
+	}
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "panic: buggy adapter") {
+			t.Errorf("reported %v", err)
+		}
+	default:
+		t.Error("panic not reported")
+	}
+}
+
+func TestSetStampAtWrite(t *testing.T) {
+	for _, atWrite := range []bool{false, true} {
+		al := NewAppLogger()
+		al.RemoveLogger(AdapterConsole)
+		g := &gateLogger{gate: make(chan struct{})}
+		al.AddAdapter("gate", g)
+		al.SetStampAtWrite(atWrite)
+		al.Async(10)
+
+		// 第一条占住 worker，第二条在队列里等
+		al.Info("blocking")
+		logged := time.Now()
+		al.Info("queued")
+		time.Sleep(50 * time.Millisecond)
+		released := time.Now()
+		close(g.gate)
+		al.Close()
+
+		records := g.all()
+		if len(records) != 2 {
+			t.Fatalf("got %q", g.lines())
+		}
+		when := records[1].When
+		if atWrite && when.Before(released) {
+			t.Errorf("stamped at %v, before the write at %v", when, released)
+		}
+		if !atWrite && (when.Before(logged) || !when.Before(released)) {
+			t.Errorf("stamped at %v, want the call time %v", when, logged)
+		}
+	}
+}
+
+func TestSetEnqueueTimeout(t *testing.T) {
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	g := &gateLogger{gate: make(chan struct{})}
+	al.AddAdapter("gate", g)
+	var reported int32
+	al.SetErrorHandler(func(name string, err error) {
+		if name == "async" {
+			atomic.AddInt32(&reported, 1)
+		}
+	})
+	al.Async(1)
+	al.SetEnqueueTimeout(30 * time.Millisecond)
+
+	al.Info("taken by the worker")
+	time.Sleep(10 * time.Millisecond)
+	al.Info("queued")
+	start := time.Now()
+	al.Info("dropped")
+	if d := time.Since(start); d < 30*time.Millisecond || d > time.Second {
+		t.Errorf("waited %v for a full queue", d)
+	}
+	if n := al.DroppedCount(); n != 1 {
+		t.Errorf("DroppedCount() = %d", n)
+	}
+
+	// 在超时之前腾出位置的log照常写出
+	al.SetEnqueueTimeout(time.Second)
+	time.AfterFunc(20*time.Millisecond, func() { close(g.gate) })
+	al.Info("waited")
+	al.Close()
+
+	if got := strings.Join(g.lines(), "|"); got != "[I]  taken by the worker|[I]  queued|[I]  waited" {
+		t.Errorf("got %q", got)
+	}
+	if n := al.DroppedCount(); n != 1 {
+		t.Errorf("DroppedCount() = %d after Close", n)
+	}
+	if atomic.LoadInt32(&reported) != 1 {
+		t.Errorf("%d drops reported", reported)
+	}
+}
+
+// 超出范围的级别不会越界 panic
+func TestOutOfRangeLevel(t *testing.T) {
+	al, c := newTestLogger(t)
+	var buf bytes.Buffer
+	al.AddWriter("buf", &buf, LevelDebug)
+	al.Log(-5, "stray")
+	al.SetLevelStyle(LevelStyleLong)
+	al.Log(-5, "stray again")
+	al.Close()
+	if got := strings.Join(c.lines(), "|"); got != "[?]  stray|[?]  stray again" {
+		t.Errorf("got %q", got)
+	}
+
+	for _, level := range []int{-5, LevelDebug + 1, 99} {
+		if tok := levelToken(LevelStyleShort, level); tok != unknownLevelToken {
+			t.Errorf("levelToken(%d) = %q", level, tok)
+		}
+		if p := defaultPalette; p.colorLevelPrefix("[?]  x", level) != "[?]  x" || p.brush(level)("x") != "x" {
+			t.Errorf("level %d colored", level)
+		}
+		for _, cfg := range []string{`{"color":true}`, `{"color":true,"color_full_line":true}`, `{"level_attrs":{"error":"bold"}}`, `{"json":true}`} {
+			var out bytes.Buffer
+			cw := newTestConsole(t, &out, cfg)
+			cw.Level = 99
+			cw.WriteMsg(time.Now(), "[?]  x", level)
+			cw.WriteRecord(&Record{When: time.Now(), Level: level, Msg: "x", text: "[?]  x"})
+		}
+		JSONFormatter{}.Format(time.Now(), level, "x", nil)
+		LogfmtFormatter{}.Format(time.Now(), level, "x", nil)
+	}
+}
+
+func TestWaitIdle(t *testing.T) {
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	slow := &slowLogger{delay: 100 * time.Microsecond}
+	al.AddAdapter("slow", slow)
+	al.Async(1000)
+	defer al.Close()
+	for i := 0; i < 200; i++ {
+		al.Info("burst %d", i)
+	}
+	if err := al.WaitIdle(5 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(al.msgChan); n != 0 {
+		t.Errorf("%d messages still in the channel", n)
+	}
+	if n := len(slow.all()); n != 200 {
+		t.Errorf("%d of 200 written", n)
+	}
+	if slow.flushed != 0 {
+		t.Error("WaitIdle flushed the Logger")
+	}
+}
+
+func TestWaitIdleTimeout(t *testing.T) {
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	g := &gateLogger{gate: make(chan struct{})}
+	al.AddAdapter("gate", g)
+	al.Async(10)
+	al.Info("stuck")
+	al.Info("queued")
+	start := time.Now()
+	err := al.WaitIdle(30 * time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "2 messages") {
+		t.Errorf("WaitIdle() = %v", err)
+	}
+	if d := time.Since(start); d < 30*time.Millisecond {
+		t.Errorf("returned after %v", d)
+	}
+	close(g.gate)
+	if err := al.WaitIdle(0); err != nil {
+		t.Error(err)
+	}
+	al.Close()
+}
+
+// severityError 带着级别的错误
+type severityError struct {
+	msg   string
+	level int
+}
+
+func (e severityError) Error() string { return e.msg }
+func (e severityError) Severity() int { return e.level }
+
+func TestLogErr(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.SetLevel(LevelInfo)
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{severityError{"bad email", LevelWarning}, "[W]  request rejected error=bad email"},
+		{fmt.Errorf("insert: %w", severityError{"deadlock", LevelError}), "[E]  request rejected error=insert: deadlock"},
+		{severityError{"cache miss", LevelInfo}, "[I]  request rejected error=cache miss"},
+		{severityError{"odd", 42}, "[E]  request rejected error=odd"},
+		{errors.New("plain"), "[E]  request rejected error=plain"},
+		{severityError{"noise", LevelDebug}, ""},
+		{nil, ""},
+	}
+	for _, tc := range cases {
+		c.reset()
+		al.LogErr(tc.err, "request %s", "rejected")
+		if got := strings.Join(c.lines(), "|"); got != tc.want {
+			t.Errorf("LogErr(%v) wrote %q, want %q", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestEnableSequence(t *testing.T) {
+	al, c := newTestLogger(t)
+	al.Info("unnumbered")
+	al.EnableSequence(true)
+	al.Info("first")
+	al.Write([]byte("untagged\n"))
+	if got := strings.Join(c.lines(), "|"); got != "[I]  unnumbered|[I] #000001  first|#000002  untagged" {
+		t.Errorf("got %q", got)
+	}
+
+	c.reset()
+	al.AsyncWorkers(4).Async()
+	const goroutines, each = 8, 100
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < each; i++ {
+				al.Info("concurrent")
+			}
+		}()
+	}
+	wg.Wait()
+	al.Close()
+
+	records := c.all()
+	if len(records) != goroutines*each {
+		t.Fatalf("%d records", len(records))
+	}
+	seen := make(map[uint64]bool)
+	for _, r := range records {
+		if seen[r.Seq] || !strings.HasPrefix(r.text, fmt.Sprintf("[I] #%06d ", r.Seq)) {
+			t.Fatalf("duplicate or mismatched seq in %q", r.text)
+		}
+		seen[r.Seq] = true
+	}
+	for seq := uint64(3); seq < 3+goroutines*each; seq++ {
+		if !seen[seq] {
+			t.Fatalf("gap at %d", seq)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+//go:build go1.14
+// +build go1.14
+
+// Package logstest 在测试里收集 AppLogger 写出的log，并提供断言的帮助函数
+package logstest
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/senkasng/logs"
+)
+
+// AdapterCapture NewTestLogger 添加的 Logger 的名字
+const AdapterCapture = "logstest"
+
+// Capture 保存写到它的所有log，可以并发使用
+type Capture struct {
+	t       testing.TB
+	mu      sync.Mutex
+	records []logs.Record
+}
+
+// NewTestLogger 返回只写到 Capture 的 AppLogger，所有级别都打开；测试结束时自动 Close
+func NewTestLogger(t testing.TB) (*logs.AppLogger, *Capture) {
+	al := logs.NewAppLogger()
+	al.RemoveLogger(logs.AdapterConsole)
+	c := &Capture{t: t}
+	if err := al.AddAdapter(AdapterCapture, c); err != nil {
+		t.Fatalf("logstest: %v", err)
+	}
+	t.Cleanup(al.Close)
+	return al, c
+}
+
+// Init implementing method. empty.
+func (c *Capture) Init(jsonConfig string) error {
+	return nil
+}
+
+// WriteMsg save message as a record without fields.
+func (c *Capture) WriteMsg(when time.Time, msg string, level int) error {
+	return c.WriteRecord(&logs.Record{When: when, Level: level, Msg: msg})
+}
+
+// WriteRecord save a copy of r.
+func (c *Capture) WriteRecord(r *logs.Record) error {
+	rec := *r
+	if r.Fields != nil {
+		rec.Fields = make(map[string]interface{}, len(r.Fields))
+		for k, v := range r.Fields {
+			rec.Fields[k] = v
+		}
+	}
+	c.mu.Lock()
+	c.records = append(c.records, rec)
+	c.mu.Unlock()
+	return nil
+}
+
+// Destroy implementing method. empty.
+func (c *Capture) Destroy() {}
+
+// Flush implementing method. empty.
+func (c *Capture) Flush() {}
+
+// Records 返回到目前为止写出的所有log
+func (c *Capture) Records() []logs.Record {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]logs.Record(nil), c.records...)
+}
+
+// Lines 返回到目前为止写出的所有log，每条一行，如 "[I] [main.go:12] prefix msg"
+func (c *Capture) Lines() []string {
+	records := c.Records()
+	lines := make([]string, len(records))
+	for i := range records {
+		lines[i] = line(&records[i])
+	}
+	return lines
+}
+
+// Reset 清空已经保存的log
+func (c *Capture) Reset() {
+	c.mu.Lock()
+	c.records = nil
+	c.mu.Unlock()
+}
+
+// AssertContains 检查是否有一条log包含 substr，没有时报告测试失败并列出所有log，返回是否通过
+func (c *Capture) AssertContains(substr string) bool {
+	c.t.Helper()
+	for _, l := range c.Lines() {
+		if strings.Contains(l, substr) {
+			return true
+		}
+	}
+	c.t.Errorf("logstest: no log contains %q, got:\n%s", substr, strings.Join(c.Lines(), "\n"))
+	return false
+}
+
+// AssertLevel 检查是否有一条 level 级别的log包含 substr，没有时报告测试失败并列出所有log，返回是否通过
+func (c *Capture) AssertLevel(level int, substr string) bool {
+	c.t.Helper()
+	records := c.Records()
+	for i := range records {
+		if records[i].Level == level && strings.Contains(line(&records[i]), substr) {
+			return true
+		}
+	}
+	c.t.Errorf("logstest: no log at level %d contains %q, got:\n%s", level, substr, strings.Join(c.Lines(), "\n"))
+	return false
+}
+
+// line 返回 r 的整行内容，没有时（如直接调用 WriteMsg）使用 Msg
+func line(r *logs.Record) string {
+	if s := r.String(); s != "" {
+		return s
+	}
+	return r.Msg
+}
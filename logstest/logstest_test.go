@@ -0,0 +1,108 @@
+//go:build go1.14
+// +build go1.14
+
+package logstest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senkasng/logs"
+)
+
+// fakeTB 记录断言报告的失败，不让外层测试失败
+type fakeTB struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) Cleanup(fn func()) {
+	f.cleanups = append(f.cleanups, fn)
+}
+
+func (f *fakeTB) cleanup() {
+	for i := len(f.cleanups) - 1; i >= 0; i-- {
+		f.cleanups[i]()
+	}
+}
+
+func newFake(t *testing.T) (*fakeTB, *logs.AppLogger, *Capture) {
+	f := &fakeTB{TB: t}
+	al, c := NewTestLogger(f)
+	t.Cleanup(f.cleanup)
+	return f, al, c
+}
+
+func TestAssertContains(t *testing.T) {
+	f, al, c := newFake(t)
+	al.Info("user %s logged in", "alice")
+
+	if !c.AssertContains("alice logged in") || len(f.errors) != 0 {
+		t.Errorf("passing assertion failed: %q", f.errors)
+	}
+	if c.AssertContains("bob") {
+		t.Error("failing assertion passed")
+	}
+	if len(f.errors) != 1 || !strings.Contains(f.errors[0], `"bob"`) || !strings.Contains(f.errors[0], "alice logged in") {
+		t.Errorf("failure report %q", f.errors)
+	}
+}
+
+func TestAssertLevel(t *testing.T) {
+	f, al, c := newFake(t)
+	al.Warn("disk at %d%%", 91)
+
+	if !c.AssertLevel(logs.LevelWarning, "disk at 91%") || len(f.errors) != 0 {
+		t.Errorf("passing assertion failed: %q", f.errors)
+	}
+	if c.AssertLevel(logs.LevelError, "disk at 91%") {
+		t.Error("assertion passed at the wrong level")
+	}
+	if len(f.errors) != 1 || !strings.Contains(f.errors[0], "disk at 91%") {
+		t.Errorf("failure report %q", f.errors)
+	}
+}
+
+func TestRecordsAndReset(t *testing.T) {
+	_, al, c := newFake(t)
+	al.Info("one")
+	al.Error("two")
+	c.WriteMsg(time.Now(), "raw", logs.LevelDebug)
+
+	records := c.Records()
+	if len(records) != 3 || records[0].Msg != "one" || records[1].Level != logs.LevelError {
+		t.Fatalf("got %+v", records)
+	}
+	lines := c.Lines()
+	if !strings.Contains(lines[1], "two") || lines[2] != "raw" {
+		t.Errorf("got %q", lines)
+	}
+
+	c.Reset()
+	if n := len(c.Records()); n != 0 {
+		t.Errorf("%d records after Reset", n)
+	}
+	if c.AssertContains("one") {
+		t.Error("assertion passed after Reset")
+	}
+}
+
+// NewTestLogger 在测试结束时 Close
+func TestNewTestLoggerCleanup(t *testing.T) {
+	f := &fakeTB{TB: t}
+	al, c := NewTestLogger(f)
+	f.cleanup()
+	al.Info("after close")
+	if n := len(c.Records()); n != 0 {
+		t.Errorf("%d records after cleanup", n)
+	}
+}
@@ -0,0 +1,74 @@
+package logs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// shortWriter 每次最多写 n 个字节，n 为 0 时什么都不写也不报错
+type shortWriter struct {
+	buf bytes.Buffer
+	n   int
+}
+
+func (w *shortWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		p = p[:w.n]
+	}
+	return w.buf.Write(p)
+}
+
+// errWriter 每次写入都返回 err
+type errWriter struct{ err error }
+
+func (w errWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
+
+func TestLogWriterShortWrites(t *testing.T) {
+	w := &shortWriter{n: 3}
+	lg := newLogWriter(w)
+	n, err := lg.writeln(time.Now(), "a message longer than three bytes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != w.buf.Len() || !strings.HasSuffix(w.buf.String(), "a message longer than three bytes\n") {
+		t.Errorf("wrote %d bytes: %q", n, w.buf.String())
+	}
+
+	_, err = newLogWriter(&shortWriter{}).writeln(time.Now(), "msg")
+	if err != io.ErrShortWrite {
+		t.Errorf("got %v, want io.ErrShortWrite", err)
+	}
+}
+
+func TestWriteErrorsReturned(t *testing.T) {
+	inTempDir(t)
+	errDisk := errors.New("no space left on device")
+
+	console := NewConsole().(*consoleWriter)
+	console.lg.writer = errWriter{errDisk}
+	colorLine := NewConsole().(*consoleWriter)
+	colorLine.Init(`{"color_full_line":true}`)
+	colorLine.lg.writer = errWriter{errDisk}
+
+	file := NewFile().(*fileWriter)
+	defer file.Destroy()
+	file.lg.writer = errWriter{errDisk}
+
+	writer := NewWriterAdapter(errWriter{errDisk}, LevelDebug)
+
+	for name, lg := range map[string]Logger{"console": console, "color_full_line": colorLine, "file": file, "writer": writer} {
+		if err := lg.WriteMsg(time.Now(), "[I] msg", LevelInfo); err != errDisk {
+			t.Errorf("%s: WriteMsg returned %v", name, err)
+		}
+		r := &Record{When: time.Now(), Level: LevelInfo, Msg: "msg", text: "[I] msg"}
+		if err := lg.(RecordWriter).WriteRecord(r); err != errDisk {
+			t.Errorf("%s: WriteRecord returned %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package logs
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// moduleLevels 按调用方的包设置的级别
+type moduleLevels struct {
+	sync.RWMutex
+	levels map[string]int
+	max    int32    // 所有模块中最高的级别 + 1，0 表示没有设置模块级别
+	cache  sync.Map // pc -> 这个调用位置适用的级别，没有匹配的模块时为 levelLoggerImpl
+}
+
+// SetModuleLevel 设置 module 中的代码写log的级别，覆盖全局级别。module 是包的导入路径或者它的结尾部分，
+// 如 "db" 匹配 ".../app/db" 以及它下面的子包 ".../app/db/mysql"；有多个模块匹配时以最长的为准
+func (al *AppLogger) SetModuleLevel(module string, level int) {
+	m := &al.modules
+	m.Lock()
+	defer m.Unlock()
+	if m.levels == nil {
+		m.levels = make(map[string]int)
+	}
+	m.levels[strings.Trim(module, "/")] = level
+	m.reset()
+}
+
+// ClearModuleLevel 去掉 module 的级别，module 中的代码重新使用全局级别
+func (al *AppLogger) ClearModuleLevel(module string) {
+	m := &al.modules
+	m.Lock()
+	defer m.Unlock()
+	delete(m.levels, strings.Trim(module, "/"))
+	m.reset()
+}
+
+// reset 重新计算最高级别并清空缓存；调用时需持有写锁
+func (m *moduleLevels) reset() {
+	max := int32(0)
+	for _, level := range m.levels {
+		if int32(level)+1 > max {
+			max = int32(level) + 1
+		}
+	}
+	atomic.StoreInt32(&m.max, max)
+	m.cache.Range(func(k, _ interface{}) bool {
+		m.cache.Delete(k)
+		return true
+	})
+}
+
+// maxLevel 返回所有模块中最高的级别，ok 为 false 表示没有设置模块级别
+func (m *moduleLevels) maxLevel() (level int, ok bool) {
+	max := atomic.LoadInt32(&m.max)
+	return int(max) - 1, max > 0
+}
+
+// levelAt 返回 pc 所在的包适用的级别，ok 为 false 表示没有匹配的模块
+func (m *moduleLevels) levelAt(pc uintptr) (level int, ok bool) {
+	if v, cached := m.cache.Load(pc); cached {
+		level = v.(int)
+		return level, level != levelLoggerImpl
+	}
+	pkg := ""
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		pkg = packagePath(fn.Name())
+	}
+	m.RLock()
+	level, matched := levelLoggerImpl, 0
+	for module, l := range m.levels {
+		if len(module) > matched && matchModule(pkg, module) {
+			level, matched = l, len(module)
+		}
+	}
+	m.RUnlock()
+	m.cache.Store(pc, level)
+	return level, level != levelLoggerImpl
+}
+
+// packagePath 从函数全名中取出包的导入路径，如 "github.com/a/app/db.(*Conn).Query" 返回 "github.com/a/app/db"
+func packagePath(funcName string) string {
+	dir := ""
+	if i := strings.LastIndexByte(funcName, '/'); i >= 0 {
+		dir, funcName = funcName[:i+1], funcName[i+1:]
+	}
+	if i := strings.IndexByte(funcName, '.'); i >= 0 {
+		funcName = funcName[:i]
+	}
+	return dir + funcName
+}
+
+// matchModule 判断 module 是不是 pkg 或者 pkg 的父包，按路径中完整的段匹配
+func matchModule(pkg, module string) bool {
+	for {
+		if pkg == module || strings.HasSuffix(pkg, "/"+module) {
+			return true
+		}
+		i := strings.LastIndexByte(pkg, '/')
+		if i < 0 {
+			return false
+		}
+		pkg = pkg[:i]
+	}
+}
+
+// moduleAllows 设置了模块级别时，按调用方所在的包判断 level 的log是否写出；
+// 只能由 writeMsg 这一类函数直接调用，否则调用位置会算错；skip 见 AppLogger.output
+func (al *AppLogger) moduleAllows(skip int, level int) bool {
+	if _, ok := al.modules.maxLevel(); !ok || level == levelLoggerImpl {
+		return true
+	}
+	// 多一层是 writeMsg 本身
+	pc, _, _, ok := runtime.Caller(al.loggerFuncCallDepth + 1 + skip)
+	if !ok {
+		return al.levelEnabled(level)
+	}
+	if moduleLevel, ok := al.modules.levelAt(pc); ok {
+		return level <= moduleLevel
+	}
+	return al.levelEnabled(level)
+}
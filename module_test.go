@@ -0,0 +1,51 @@
+package logs
+
+import (
+	"testing"
+)
+
+func TestSetModuleLevel(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.SetLevel(LevelInfo)
+
+	al.SetModuleLevel("senkasng/logs", LevelDebug)
+	al.Debug("raised")
+	al.SetModuleLevel("github.com/senkasng/logs/", LevelError)
+	al.Info("lowered by the longer match")
+	al.Error("still written")
+	al.ClearModuleLevel("github.com/senkasng/logs")
+	al.Debug("raised again")
+	al.ClearModuleLevel("senkasng/logs")
+	al.Debug("global level")
+
+	lines := c.lines()
+	want := []string{"[D]  raised", "[E]  still written", "[D]  raised again"}
+	if len(lines) != len(want) {
+		t.Fatalf("got %q", lines)
+	}
+	for i, w := range want {
+		if lines[i] != w {
+			t.Errorf("line %d = %q, want %q", i, lines[i], w)
+		}
+	}
+}
+
+func TestMatchModule(t *testing.T) {
+	cases := []struct {
+		funcName, module string
+		want             bool
+	}{
+		{"github.com/a/app/db.(*Conn).Query", "db", true},
+		{"github.com/a/app/db/mysql.Open", "db", true},
+		{"github.com/a/app/db/mysql.Open", "app/db", true},
+		{"github.com/a/app/dbx.Open", "db", false},
+		{"github.com/a/app.main", "db", false},
+		{"main.main", "main", true},
+	}
+	for _, c := range cases {
+		if got := matchModule(packagePath(c.funcName), c.module); got != c.want {
+			t.Errorf("matchModule(%q, %q) = %v", packagePath(c.funcName), c.module, got)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package logs
+
+import (
+	"sync"
+	"time"
+)
+
+// 已经写过的 LogOnce key，以及写出的时间
+type onceKeys struct {
+	sync.Mutex
+	window time.Duration
+	seen   map[string]time.Time
+}
+
+// LogOnce 以 level 级别写log，同一个 key 在进程生命周期内只写一次，适合弃用警告、一次性的配置提示；
+// 用 SetLogOnceWindow 设置时间窗口后，同一个 key 每个窗口内最多写一次
+func (al *AppLogger) LogOnce(key string, level int, format string, v ...interface{}) {
+	if !al.Enabled(level) || !al.once.mark(key) {
+		return
+	}
+	al.writeMsg(level, nil, format, v...)
+}
+
+// SetLogOnceWindow 设置 LogOnce 同一个 key 再次写出的最小间隔，d <= 0 表示只写一次
+func (al *AppLogger) SetLogOnceWindow(d time.Duration) {
+	al.once.Lock()
+	al.once.window = d
+	al.once.Unlock()
+}
+
+// mark 记录 key，返回这次是否应该写出
+func (o *onceKeys) mark(key string) bool {
+	o.Lock()
+	defer o.Unlock()
+	now := time.Now()
+	if last, ok := o.seen[key]; ok && (o.window <= 0 || now.Sub(last) < o.window) {
+		return false
+	}
+	if o.seen == nil {
+		o.seen = make(map[string]time.Time)
+	}
+	o.seen[key] = now
+	return true
+}
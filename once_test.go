@@ -0,0 +1,47 @@
+package logs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLogOnce(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	for i := 0; i < 3; i++ {
+		al.LogOnce("deprecated-flag", LevelWarning, "flag -x is deprecated (%d)", i)
+		al.LogOnce("other", LevelInfo, "other key")
+	}
+	lines := c.lines()
+	if len(lines) != 2 || lines[0] != "[W]  flag -x is deprecated (0)" {
+		t.Errorf("got %q", lines)
+	}
+}
+
+func TestLogOnceWindow(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.SetLogOnceWindow(50 * time.Millisecond)
+	al.LogOnce("k", LevelInfo, "first")
+	al.LogOnce("k", LevelInfo, "suppressed")
+	time.Sleep(60 * time.Millisecond)
+	al.LogOnce("k", LevelInfo, "next window")
+
+	lines := c.lines()
+	if len(lines) != 2 || lines[1] != "[I]  next window" {
+		t.Errorf("got %q", lines)
+	}
+}
+
+// 级别关闭时不占用 key
+func TestLogOnceDisabledLevel(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.SetLevel(LevelWarning)
+	al.LogOnce("k", LevelInfo, "hidden")
+	al.SetLevel(LevelInfo)
+	al.LogOnce("k", LevelInfo, "shown")
+	if lines := c.lines(); len(lines) != 1 || lines[0] != "[I]  shown" {
+		t.Errorf("got %q", lines)
+	}
+}
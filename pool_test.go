@@ -0,0 +1,39 @@
+package logs
+
+import (
+	"io/ioutil"
+	"runtime"
+	"testing"
+	"time"
+)
+
+type pooledArg struct {
+	buf []byte
+}
+
+func (a *pooledArg) String() string { return "huge" }
+
+// 写完放回 logMsgPool 的 logMsg 不再引用log的参数，一阵很长的log过去之后池里不会留住它们
+func TestPoolMsgReleasesArgs(t *testing.T) {
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	al.AddWriter("discard", ioutil.Discard, LevelDebug)
+	al.Async()
+	defer al.Close()
+
+	freed := make(chan struct{})
+	func() {
+		arg := &pooledArg{buf: make([]byte, 4<<20)}
+		runtime.SetFinalizer(arg, func(*pooledArg) { close(freed) })
+		al.Info("%v", arg)
+	}()
+	al.Flush()
+
+	// 只做一次 GC：池里的对象这时还在 victim cache 里，如果 logMsg 还引用着参数，参数不会被回收
+	runtime.GC()
+	select {
+	case <-freed:
+	case <-time.After(time.Second):
+		t.Fatal("pooled logMsg still references the message arguments")
+	}
+}
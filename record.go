@@ -0,0 +1,36 @@
+package logs
+
+import (
+	"time"
+)
+
+// Record 一条log的结构化内容。实现了 RecordWriter 的 Logger 会直接收到 Record，
+// 可以按自己的格式输出，而不是去解析拼好的字符串
+type Record struct {
+	When   time.Time
+	Level  int
+	Format string        // 调用时传入的 format
+	Args   []interface{} // 调用时传入的参数
+	Msg    string        // 格式化之后的消息，不含级别、调用位置和前缀
+	Fields map[string]interface{}
+	File   string // 调用位置的文件名，没有开启 enableFuncCallDepth 时为空
+	Line   int
+	Func   string // 调用位置的 包名.函数名，只在开启 EnableFuncName 时有值
+	Prefix string
+	Seq    uint64 // EnableSequence 分配的序号，没有开启时为 0
+
+	text          string        // 拼好的整行，交给只实现了 WriteMsg 的 Logger
+	precision     time.Duration // 时间头小数部分的精度，见 AppLogger.SetTimePrecision
+	noLevel       bool          // levelLoggerImpl 写入的log，没有级别标记
+	severityScale int           // JSON 输出中 severity 字段的取值方式，见 AppLogger.SetSeverityScale
+}
+
+// String 返回 WriteMsg 收到的整行内容，如 "[I] [main.go:12] prefix msg"
+func (r *Record) String() string {
+	return r.text
+}
+
+// RecordWriter 可以直接处理 Record 的 Logger，实现了这个接口的 Logger 不再调用 WriteMsg
+type RecordWriter interface {
+	WriteRecord(r *Record) error
+}
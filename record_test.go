@@ -0,0 +1,112 @@
+package logs
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// msgLogger 只实现了 WriteMsg
+type msgLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (m *msgLogger) Init(jsonConfig string) error { return nil }
+func (m *msgLogger) Destroy()                     {}
+func (m *msgLogger) Flush()                       {}
+
+func (m *msgLogger) WriteMsg(when time.Time, msg string, level int) error {
+	m.mu.Lock()
+	m.lines = append(m.lines, msg)
+	m.mu.Unlock()
+	return nil
+}
+
+func TestRecordWriter(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	plain := &msgLogger{}
+	al.AddAdapter("plain", plain)
+	al.EnableFuncCallDepth(true)
+
+	start := time.Now()
+	al.Log(LevelWarning, "slow query", Int("ms", 1200))
+	al.Info("%d rows", 3)
+
+	records := c.all()
+	if len(records) != 2 {
+		t.Fatalf("got %d records", len(records))
+	}
+	r := records[0]
+	if r.Level != LevelWarning || r.Msg != "slow query" || r.Fields["ms"] != 1200 || r.When.Before(start) {
+		t.Errorf("got %+v", r)
+	}
+	if r.File != "record_test.go" || r.Line == 0 {
+		t.Errorf("caller %s:%d", r.File, r.Line)
+	}
+	r = records[1]
+	if r.Format != "%d rows" || len(r.Args) != 1 || r.Args[0] != 3 || r.Msg != "3 rows" {
+		t.Errorf("got %+v", r)
+	}
+
+	// 只实现了 WriteMsg 的 Logger 收到的是 Record.String()
+	if len(plain.lines) != 2 || plain.lines[1] != r.String() || !strings.HasSuffix(r.String(), "3 rows") {
+		t.Errorf("WriteMsg got %q, String() = %q", plain.lines, r.String())
+	}
+}
+
+func TestLogFrom(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.SetLevel(LevelWarning)
+	al.LogFrom("db/conn.go", 42, LevelError, "connection reset")
+	al.LogFrom("db/conn.go", 43, LevelInfo, "filtered")
+	al.EnableFuncCallDepth(true)
+	al.LogFrom("cache.go", 7, LevelWarning, "miss")
+
+	records := c.all()
+	if len(records) != 2 {
+		t.Fatalf("got %q", c.lines())
+	}
+	if r := records[0]; r.File != "db/conn.go" || r.Line != 42 || r.Level != LevelError || r.text != "[E] [db/conn.go:42]  connection reset" {
+		t.Errorf("got %+v, %q", r, r.text)
+	}
+	if r := records[1]; r.File != "cache.go" || r.Line != 7 || r.text != "[W] [cache.go:7]  miss" {
+		t.Errorf("got %+v, %q", r, r.text)
+	}
+}
+
+// LogFrom 和 Log 一样处理空消息和 SyncFrom
+func TestLogFromLikeLog(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.LogFrom("a.go", 1, LevelInfo, "")
+	al.SetEmptyMsgPlaceholder("<empty>")
+	al.LogFrom("a.go", 2, LevelInfo, "")
+	if lines := c.lines(); len(lines) != 1 || lines[0] != "[I] [a.go:2]  <empty>" {
+		t.Errorf("got %q", lines)
+	}
+
+	gate := &gateLogger{gate: make(chan struct{})}
+	al.AddAdapter("gate", gate)
+	al.Async()
+	al.SyncFrom(LevelError)
+	wrote := make(chan struct{})
+	go func() {
+		al.LogFrom("a.go", 3, LevelError, "synced")
+		close(wrote)
+	}()
+	select {
+	case <-wrote:
+		close(gate.gate)
+		t.Fatal("LogFrom returned before the record was written")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(gate.gate)
+	<-wrote
+	if lines := c.lines(); len(lines) != 2 || lines[1] != "[E] [a.go:3]  synced" {
+		t.Errorf("got %q", lines)
+	}
+}
@@ -0,0 +1,125 @@
+package logs
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// goRecovered 在新的 goroutine 里执行 f，f 里 defer al.Recover()；返回重新 panic 的值和那时已经写出的log
+func goRecovered(al *AppLogger, c *captureLogger, f func()) (repanicked interface{}, written []Record) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() {
+			repanicked = recover()
+			written = c.all()
+		}()
+		defer al.Recover()
+		f()
+	}()
+	<-done
+	return
+}
+
+func TestRecover(t *testing.T) {
+	al, c := newTestLogger(t)
+	al.Async()
+	defer al.Close()
+	al.EnableFuncCallDepth(true)
+
+	_, _, panicLine, _ := runtime.Caller(0)
+	p, written := goRecovered(al, c, func() { panic("boom") })
+	panicLine += 1
+
+	if p != "boom" {
+		t.Errorf("re-panicked with %v", p)
+	}
+	// 重新 panic 之前已经写出
+	if len(written) != 1 {
+		t.Fatalf("%d records written before re-panic", len(written))
+	}
+	r := written[0]
+	if r.Level != LevelError || !strings.HasPrefix(r.Msg, "panic: boom\n") || !strings.Contains(r.Msg, "goroutine ") {
+		t.Errorf("got %q at level %d", r.Msg, r.Level)
+	}
+	if r.File != "recover_test.go" || r.Line != panicLine {
+		t.Errorf("caller %s:%d, want recover_test.go:%d", r.File, r.Line, panicLine)
+	}
+}
+
+func TestRecoverRuntimeError(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.EnableFuncCallDepth(true)
+	al.EnableFuncName(true)
+
+	var m map[string]int
+	p, written := goRecovered(al, c, func() { m["x"] = 1 })
+	if _, ok := p.(runtime.Error); !ok {
+		t.Fatalf("re-panicked with %v", p)
+	}
+	if len(written) != 1 {
+		t.Fatalf("%d records written", len(written))
+	}
+	if r := written[0]; r.File != "recover_test.go" || !strings.Contains(r.Func, "TestRecoverRuntimeError") {
+		t.Errorf("caller %s:%d %s", r.File, r.Line, r.Func)
+	}
+}
+
+func TestRecoverWithoutPanic(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	func() {
+		defer al.Recover()
+	}()
+	if n := len(c.all()); n != 0 {
+		t.Errorf("%d records without a panic", n)
+	}
+}
+
+// panicPoint 测试用的 panic 值
+type panicPoint struct {
+	X, Y int
+}
+
+func TestLogRecovered(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	cases := []struct {
+		value    interface{}
+		typ, msg string
+	}{
+		{"plain string", "string", "panic: plain string\n"},
+		{errors.New("boom"), "*errors.errorString", "panic: boom\n"},
+		{panicPoint{1, 2}, "logs.panicPoint", "panic: {X:1 Y:2}\n"},
+		{42, "int", "panic: 42\n"},
+	}
+	for _, tc := range cases {
+		c.reset()
+		func() {
+			defer func() { al.LogRecovered(recover()) }()
+			panic(tc.value)
+		}()
+		records := c.all()
+		if len(records) != 1 {
+			t.Fatalf("%T: got %q", tc.value, c.lines())
+		}
+		r := records[0]
+		if r.Level != LevelError || r.Fields["panic_type"] != tc.typ || !strings.HasPrefix(r.Msg, tc.msg) {
+			t.Errorf("%T: got %+v", tc.value, r)
+		}
+		if !strings.Contains(r.Msg, "logs.TestLogRecovered") {
+			t.Errorf("%T: no stack in %q", tc.value, r.Msg)
+		}
+	}
+
+	c.reset()
+	func() {
+		defer func() { al.LogRecovered(recover()) }()
+	}()
+	if n := len(c.all()); n != 0 {
+		t.Errorf("%d records without a panic", n)
+	}
+}
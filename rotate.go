@@ -0,0 +1,130 @@
+package logs
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// RotateNamer 返回切分时归档文件的名字，base 为当前的文件名，index 从 1 开始，
+// 返回的名字已经存在或者和其他文件冲突时会用 index+1 再调用一次
+type RotateNamer func(base string, index int, when time.Time) string
+
+// 一个文件最多尝试这么多个 index，都冲突时放弃切分
+const maxRotateIndex = 10000
+
+// defaultRotateNamer 缺省的归档文件名，如 app.log.1、app.log.2
+func defaultRotateNamer(base string, index int, when time.Time) string {
+	return fmt.Sprintf("%s.%d", base, index)
+}
+
+// SetRotateNamer 设置切分时归档文件的命名方式，fn 为 nil 时恢复缺省的 "<文件名>.<序号>"
+func (f *fileWriter) SetRotateNamer(fn RotateNamer) {
+	f.namer = fn
+}
+
+// SetRotateNamer 设置名为 name 的 Logger 切分时归档文件的命名方式，Logger 需要支持切分，如 file
+func (al *AppLogger) SetRotateNamer(name string, fn RotateNamer) error {
+	for _, l := range al.outputs {
+		if l.name != name {
+			continue
+		}
+		r, ok := l.Logger.(interface{ SetRotateNamer(fn RotateNamer) })
+		if !ok {
+			return fmt.Errorf("logs: adapter %q does not support rotation", name)
+		}
+		r.SetRotateNamer(fn)
+		return nil
+	}
+	return fmt.Errorf("logs: unknown adaptername %q", name)
+}
+
+// Rotate 把当前的文件同步到磁盘，按 RotateNamer 改名归档之后打开新的文件
+func (f *fileWriter) Rotate() error {
+	f.Flush()
+	return f.rotate(time.Now())
+}
+
+// Rotate 让所有实现了 Rotator 的 Logger 立即切分，如由 cron 定时切分或者备份之前；
+// 先写出异步 channel 里排队的log，期间新的log会被阻塞，返回第一个错误
+func (al *AppLogger) Rotate() error {
+	al.ensureInit()
+	if al.drain() {
+		defer al.Resume()
+	}
+	var firstErr error
+	for _, l := range al.outputs {
+		r, ok := l.Logger.(Rotator)
+		if !ok {
+			continue
+		}
+		if err := r.Rotate(); err != nil {
+			al.reportError(l.name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// rotate 关闭所有打开的文件，按 namer 改名归档之后重新打开新的文件；
+// 改名失败的文件继续追加写，返回第一个错误。调用方需保证期间没有写入
+func (f *fileWriter) rotate(when time.Time) error {
+	filenames := make([]string, 0, len(f.files))
+	for filename := range f.files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	f.closeFiles()
+
+	var firstErr error
+	taken := make(map[string]bool)
+	for _, filename := range filenames {
+		archive, err := f.archiveName(filename, when, taken)
+		if err == nil {
+			err = os.Rename(filename, archive)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if err := f.openFiles(true); err != nil {
+		return err
+	}
+	return firstErr
+}
+
+// archiveName 返回 base 的归档文件名，跳过已经存在的文件、正在使用的文件名和这次切分已经用掉的名字
+func (f *fileWriter) archiveName(base string, when time.Time, taken map[string]bool) (string, error) {
+	namer := f.namer
+	if namer == nil {
+		namer = defaultRotateNamer
+	}
+	for index := 1; index <= maxRotateIndex; index++ {
+		name := namer(base, index, when)
+		if name == "" || taken[name] || f.inUse(name) {
+			continue
+		}
+		if _, err := os.Lstat(name); !os.IsNotExist(err) {
+			continue
+		}
+		taken[name] = true
+		return name, nil
+	}
+	return "", fmt.Errorf("logs: rotate namer gave no unused name for %q", base)
+}
+
+// inUse 返回 name 是否是配置中正在写的文件
+func (f *fileWriter) inUse(name string) bool {
+	if name == f.FileName {
+		return true
+	}
+	for _, filename := range f.Files {
+		if name == filename {
+			return true
+		}
+	}
+	return false
+}
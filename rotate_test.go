@@ -0,0 +1,143 @@
+package logs
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetRotateNamer(t *testing.T) {
+	inTempDir(t)
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	defer al.Close()
+	if err := al.AddLogger(AdapterFile, `{"filename":"app.log","color":false}`); err != nil {
+		t.Fatal(err)
+	}
+	var whens []time.Time
+	err := al.SetRotateNamer(AdapterFile, func(base string, index int, when time.Time) string {
+		whens = append(whens, when)
+		if index == 1 {
+			// 和正在写的文件同名，跳过
+			return base
+		}
+		return fmt.Sprintf("archive-%s-%d", base, index)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before := time.Now()
+	al.Info("first")
+	if err := al.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	al.Info("second")
+	if err := al.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	al.Info("current")
+	al.Flush()
+
+	for name, want := range map[string]string{"archive-app.log-2": "first", "archive-app.log-3": "second", "app.log": "current"} {
+		if got := readFile(t, name); !strings.HasSuffix(got, want+"\n") || strings.Count(got, "\n") != 1 {
+			t.Errorf("%s got %q", name, got)
+		}
+	}
+	if whens[0].Before(before) {
+		t.Errorf("namer got time %v", whens[0])
+	}
+}
+
+// SetAdapterQueue 包起来的 Logger 同样可以设置 RotateNamer
+func TestSetRotateNamerQueued(t *testing.T) {
+	inTempDir(t)
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	defer al.Close()
+	if err := al.AddLogger(AdapterFile, `{"filename":"app.log","color":false}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := al.SetAdapterQueue(AdapterFile, 10, false); err != nil {
+		t.Fatal(err)
+	}
+	err := al.SetRotateNamer(AdapterFile, func(base string, index int, when time.Time) string {
+		return "archive-" + base
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	al.Info("first")
+	if err := al.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	al.Flush()
+	if got := readFile(t, "archive-app.log"); !strings.HasSuffix(got, "first\n") {
+		t.Errorf("archive-app.log got %q", got)
+	}
+}
+
+func TestRotateNamerExhausted(t *testing.T) {
+	inTempDir(t)
+	lg := NewFile().(*fileWriter)
+	if err := lg.Init(`{"filename":"app.log"}`); err != nil {
+		t.Fatal(err)
+	}
+	defer lg.Destroy()
+	lg.SetRotateNamer(func(base string, index int, when time.Time) string { return "fixed.log" })
+	if err := lg.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := lg.Rotate(); err == nil {
+		t.Error("rotated onto an existing archive")
+	}
+	if _, err := os.Stat("app.log"); err != nil {
+		t.Errorf("no current file after a failed rotation: %v", err)
+	}
+
+	al, _ := newTestLogger(t)
+	defer al.Close()
+	if err := al.SetRotateNamer("capture", nil); err == nil {
+		t.Error("set a namer on an adapter without rotation")
+	}
+	if err := al.SetRotateNamer("nope", nil); err == nil {
+		t.Error("set a namer on an unknown adapter")
+	}
+}
+
+func TestRotate(t *testing.T) {
+	inTempDir(t)
+	al, c := newTestLogger(t)
+	if err := al.AddLogger(AdapterFile, `{"filename":"app.log","color":false,"files":{"error":"err.log"}}`); err != nil {
+		t.Fatal(err)
+	}
+	al.Async()
+	for i := 0; i < 100; i++ {
+		al.Info("old %d", i)
+	}
+	al.Error("old error")
+	if err := al.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	al.Info("new")
+	al.Error("new error")
+	al.Close()
+
+	if got := readFile(t, "app.log.1"); strings.Count(got, "\n") != 100 || !strings.HasSuffix(got, "[I]  old 99\n") {
+		t.Errorf("app.log.1 has %d lines, ends with %q", strings.Count(got, "\n"), got[len(got)-20:])
+	}
+	if got := readFile(t, "err.log.1"); !strings.HasSuffix(got, "[E]  old error\n") || strings.Count(got, "\n") != 1 {
+		t.Errorf("err.log.1 got %q", got)
+	}
+	if got := readFile(t, "app.log"); !strings.HasSuffix(got, "[I]  new\n") || strings.Count(got, "\n") != 1 {
+		t.Errorf("app.log got %q", got)
+	}
+	if got := readFile(t, "err.log"); !strings.HasSuffix(got, "[E]  new error\n") || strings.Count(got, "\n") != 1 {
+		t.Errorf("err.log got %q", got)
+	}
+	if n := len(c.all()); n != 103 {
+		t.Errorf("capture got %d records", n)
+	}
+}
@@ -0,0 +1,31 @@
+package logs
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// sampleState InfoSampled 使用的随机数状态，每次加一个固定的奇数再打散（splitmix64），不需要加锁
+var sampleState = uint64(time.Now().UnixNano())
+
+// sampleFloat 返回 [0, 1) 之间的伪随机数
+func sampleFloat() float64 {
+	z := atomic.AddUint64(&sampleState, 0x9e3779b97f4a7c15)
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	z ^= z >> 31
+	return float64(z>>11) / (1 << 53)
+}
+
+// InfoSampled 以 rate 的概率写出这条 Info log，适合高频事件只需要看到一部分样本的场景；
+// rate >= 1 时总是写出，rate <= 0 时从不写出。是否写出在格式化参数之前决定
+func (al *AppLogger) InfoSampled(rate float64, format string, v ...interface{}) {
+	if rate <= 0 || rate < 1 && sampleFloat() >= rate {
+		return
+	}
+	if !al.Enabled(LevelInfo) {
+		return
+	}
+	al.guardFormat(format, v)
+	al.writeMsg(LevelInfo, nil, format, v...)
+}
@@ -0,0 +1,48 @@
+package logs
+
+import (
+	"testing"
+)
+
+// countingStringer 记录被格式化的次数
+type countingStringer struct{ n *int }
+
+func (c countingStringer) String() string {
+	*c.n++
+	return "formatted"
+}
+
+func TestInfoSampled(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	const iterations = 20000
+	for i := 0; i < iterations; i++ {
+		al.InfoSampled(0.1, "event %d", i)
+	}
+	// 期望 2000 条，标准差约 42
+	if n := len(c.all()); n < 1700 || n > 2300 {
+		t.Errorf("rate 0.1 wrote %d of %d", n, iterations)
+	}
+
+	c.reset()
+	formats := 0
+	for i := 0; i < 100; i++ {
+		al.InfoSampled(0, "%v", countingStringer{&formats})
+		al.InfoSampled(-1, "%v", countingStringer{&formats})
+	}
+	if n := len(c.all()); n != 0 || formats != 0 {
+		t.Errorf("rate 0 wrote %d and formatted %d times", n, formats)
+	}
+	for i := 0; i < 100; i++ {
+		al.InfoSampled(1, "%v", countingStringer{&formats})
+	}
+	if n := len(c.all()); n != 100 || formats != 100 {
+		t.Errorf("rate 1 wrote %d and formatted %d times", n, formats)
+	}
+
+	al.EnableFuncCallDepth(true)
+	al.InfoSampled(1, "located")
+	if r := c.all()[100]; r.File != "sample_test.go" {
+		t.Errorf("caller %s:%d", r.File, r.Line)
+	}
+}
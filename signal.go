@@ -0,0 +1,83 @@
+package logs
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// InstallSignalHandlers 收到 SIGTERM/SIGINT 时先 Close，再按系统默认的方式结束进程；
+// 收到 SIGHUP 时调用 Reopen 重新打开文件。重复调用无效，用 RemoveSignalHandlers 撤销
+func (al *AppLogger) InstallSignalHandlers() {
+	al.lock.Lock()
+	defer al.lock.Unlock()
+	if al.sigChan != nil {
+		return
+	}
+	al.sigChan = make(chan os.Signal, 1)
+	al.sigStop = make(chan struct{})
+	signal.Notify(al.sigChan, syscall.SIGTERM, os.Interrupt, syscall.SIGHUP)
+	go al.handleSignals(al.sigChan, al.sigStop)
+}
+
+// RemoveSignalHandlers 撤销 InstallSignalHandlers 注册的处理
+func (al *AppLogger) RemoveSignalHandlers() {
+	al.lock.Lock()
+	defer al.lock.Unlock()
+	if al.sigChan == nil {
+		return
+	}
+	signal.Stop(al.sigChan)
+	close(al.sigStop)
+	al.sigChan = nil
+	al.sigStop = nil
+}
+
+func (al *AppLogger) handleSignals(c chan os.Signal, stop chan struct{}) {
+	for {
+		select {
+		case sig := <-c:
+			if sig == syscall.SIGHUP {
+				if err := al.Reopen(); err != nil {
+					al.reportError("signal", err)
+				}
+				continue
+			}
+			al.RemoveSignalHandlers()
+			al.Close()
+			// 上面已经 Stop 了自己的 channel，把信号再发给自己：没有别的处理时按系统默认的方式结束进程，
+			// 有别的 signal.Notify 时交给它们处理；不用 signal.Reset，那会把别人注册的处理也一并撤掉
+			p, err := os.FindProcess(os.Getpid())
+			if err == nil {
+				err = p.Signal(sig)
+			}
+			if err != nil {
+				exitFunc(1)
+			}
+			return
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Reopen 让所有实现了 Reopener 的 Logger 重新打开输出，期间新的log会被阻塞
+func (al *AppLogger) Reopen() error {
+	if al.drain() {
+		defer al.Resume()
+	}
+	var firstErr error
+	for _, l := range al.outputs {
+		r, ok := l.Logger.(Reopener)
+		if !ok {
+			continue
+		}
+		if err := r.Reopen(); err != nil {
+			al.reportError(l.name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
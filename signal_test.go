@@ -0,0 +1,111 @@
+package logs
+
+import (
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// 收到 SIGHUP 时重新打开文件，logrotate 移走的文件之后不再被写入
+func TestSignalHandlersReopen(t *testing.T) {
+	inTempDir(t)
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	defer al.Close()
+	if err := al.AddLogger(AdapterFile, `{"filename":"app.log","append":true}`); err != nil {
+		t.Fatal(err)
+	}
+	al.InstallSignalHandlers()
+	al.InstallSignalHandlers()
+	defer al.RemoveSignalHandlers()
+
+	al.Info("before rotate")
+	if err := os.Rename("app.log", "app.log.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := os.Stat("app.log"); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("app.log was not reopened after SIGHUP")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	al.Info("after rotate")
+
+	if got := readFile(t, "app.log.1"); !strings.Contains(got, "before rotate") || strings.Contains(got, "after rotate") {
+		t.Errorf("app.log.1 got %q", got)
+	}
+	if got := readFile(t, "app.log"); !strings.Contains(got, "after rotate") {
+		t.Errorf("app.log got %q", got)
+	}
+}
+
+// 收到 SIGTERM 时先写完异步队列里的log并销毁 Logger，再把信号交还给进程原来的处理
+func TestSignalHandlersTerm(t *testing.T) {
+	codes := fakeExit(t)
+	// 代替系统默认的处理接住再发出的 SIGTERM，否则测试进程会被结束
+	reraised := make(chan os.Signal, 1)
+	signal.Notify(reraised, syscall.SIGTERM)
+	defer signal.Stop(reraised)
+
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	slow := &slowLogger{delay: 5 * time.Millisecond}
+	al.AddAdapter("slow", slow)
+	al.Async(64)
+	al.InstallSignalHandlers()
+	for i := 0; i < 20; i++ {
+		al.Info("buffered %d", i)
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+
+	// 测试自己的 channel 也会收到第一次的 SIGTERM，等到 handler 关闭之后再发出的那一次
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case <-reraised:
+		case <-deadline:
+			t.Fatal("SIGTERM was not handled")
+		}
+		slow.mu.Lock()
+		destroyed := slow.destroyed
+		slow.mu.Unlock()
+		if destroyed > 0 {
+			break
+		}
+	}
+	if n := len(slow.all()); n != 20 {
+		t.Errorf("%d of 20 buffered records written", n)
+	}
+	if slow.destroyed != 1 {
+		t.Errorf("destroyed %d times", slow.destroyed)
+	}
+	if al.sigChan != nil {
+		t.Error("handlers still installed")
+	}
+	if len(*codes) != 0 {
+		t.Errorf("exit called with %v", *codes)
+	}
+}
+
+func TestRemoveSignalHandlers(t *testing.T) {
+	al, _ := newTestLogger(t)
+	defer al.Close()
+	al.InstallSignalHandlers()
+	al.RemoveSignalHandlers()
+	al.RemoveSignalHandlers()
+	if al.sigChan != nil || al.sigStop != nil {
+		t.Error("handlers still installed")
+	}
+}
@@ -0,0 +1,160 @@
+//go:build go1.21
+// +build go1.21
+
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// AdapterSlog 转发到标准库 log/slog 的 Logger
+const AdapterSlog = "slog"
+
+// slogLevels 各级别对应的 slog 级别
+var slogLevels = [LevelDebug + 1]slog.Level{
+	LevelError:   slog.LevelError,
+	LevelWarning: slog.LevelWarn,
+	LevelInfo:    slog.LevelInfo,
+	LevelDebug:   slog.LevelDebug,
+}
+
+// slogLevel 返回 level 对应的 slog 级别，未知的级别按 slog.LevelInfo
+func slogLevel(level int) slog.Level {
+	if !validLevel(level) {
+		return slog.LevelInfo
+	}
+	return slogLevels[level]
+}
+
+var (
+	slogLoggerLock sync.Mutex
+	slogLogger     *slog.Logger
+)
+
+// SetSlogLogger 设置之后通过 AddLogger 创建的 slog adapter 转发到的 *slog.Logger；
+// 多个 AppLogger 要转发到不同的 *slog.Logger 时用 NewSlogAdapter
+func SetSlogLogger(l *slog.Logger) {
+	slogLoggerLock.Lock()
+	slogLogger = l
+	slogLoggerLock.Unlock()
+}
+
+// slogWriter 把每条log按对应的 slog 级别交给 slog.Handler，字段作为 attribute
+type slogWriter struct {
+	logger *slog.Logger
+
+	Level adapterLevel `json:"level"`
+}
+
+// NewSlog create a slog writer forwarding to the logger set by SetSlogLogger.
+func NewSlog() Logger {
+	slogLoggerLock.Lock()
+	defer slogLoggerLock.Unlock()
+	return NewSlogAdapter(slogLogger)
+}
+
+// NewSlogAdapter 返回转发到 l 的 slog adapter，不受 SetSlogLogger 影响，可以直接用 AddAdapter 添加，
+// 也可以先用 Init 设置级别，如 al.AddAdapter("slog", logs.NewSlogAdapter(slog.Default()))
+func NewSlogAdapter(l *slog.Logger) Logger {
+	return &slogWriter{
+		logger: l,
+		Level:  LevelDebug,
+	}
+}
+
+// Init init slog writer.
+// jsonConfig like '{"level":2}'.
+func (s *slogWriter) Init(jsonConfig string) error {
+	if len(jsonConfig) > 0 {
+		if err := json.Unmarshal([]byte(jsonConfig), s); err != nil {
+			return err
+		}
+	}
+	if s.logger == nil {
+		return errors.New("logs: slog logger is nil (forgotten SetSlogLogger?)")
+	}
+	return nil
+}
+
+// WriteMsg forward message to slog.
+func (s *slogWriter) WriteMsg(when time.Time, msg string, level int) error {
+	if level > s.Level.get() {
+		return nil
+	}
+	return s.handle(slog.NewRecord(when, slogLevel(level), msg, 0))
+}
+
+// WriteRecord forward record to slog, with prefix, caller and fields as attributes.
+func (s *slogWriter) WriteRecord(r *Record) error {
+	if r.Level > s.Level.get() {
+		return nil
+	}
+	rec := slog.NewRecord(r.When, slogLevel(r.Level), r.Msg, 0)
+	if r.Prefix != "" {
+		rec.AddAttrs(slog.String("prefix", r.Prefix))
+	}
+	if r.File != "" {
+		rec.AddAttrs(slog.String("file", r.File), slog.Int("line", r.Line))
+	}
+	keys := make([]string, 0, len(r.Fields))
+	for k := range r.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		rec.AddAttrs(slog.Any(k, r.Fields[k]))
+	}
+	return s.handle(rec)
+}
+
+func (s *slogWriter) handle(rec slog.Record) error {
+	ctx := context.Background()
+	h := s.logger.Handler()
+	if !h.Enabled(ctx, rec.Level) {
+		return nil
+	}
+	return h.Handle(ctx, rec)
+}
+
+// GetLevel return the level of this writer.
+func (s *slogWriter) GetLevel() int {
+	return s.Level.get()
+}
+
+// SetLevel set the level of this writer.
+func (s *slogWriter) SetLevel(level int) {
+	s.Level.set(level)
+}
+
+// Destroy implementing method. empty.
+func (s *slogWriter) Destroy() {
+
+}
+
+// Flush implementing method. empty.
+func (s *slogWriter) Flush() {
+
+}
+
+// validateSlogConfig 检查 slog adapter 的配置
+func validateSlogConfig(jsonConfig string) error {
+	s := NewSlog().(*slogWriter)
+	if err := decodeConfig(jsonConfig, s); err != nil {
+		return err
+	}
+	if s.logger == nil {
+		return errors.New("logs: slog logger is nil (forgotten SetSlogLogger?)")
+	}
+	return validateLevel(s.Level.get())
+}
+
+func init() {
+	Register(AdapterSlog, NewSlog)
+	configValidators[AdapterSlog] = validateSlogConfig
+}
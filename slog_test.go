@@ -0,0 +1,69 @@
+//go:build go1.21
+// +build go1.21
+
+package logs
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateSlogConfig(t *testing.T) {
+	SetSlogLogger(nil)
+	if err := ValidateConfig(AdapterSlog, `{}`); err == nil {
+		t.Error("validated without a slog logger")
+	}
+	SetSlogLogger(slog.New(slog.NewTextHandler(ioutil.Discard, nil)))
+	defer SetSlogLogger(nil)
+	if err := ValidateConfig(AdapterSlog, `{"level":2}`); err != nil {
+		t.Error(err)
+	}
+	if err := ValidateConfig(AdapterSlog, `{"level":4}`); err == nil {
+		t.Error("validated an invalid level")
+	}
+}
+
+func TestNewSlogAdapter(t *testing.T) {
+	var bufA, bufB bytes.Buffer
+	a, b := NewAppLogger(), NewAppLogger()
+	for al, buf := range map[*AppLogger]*bytes.Buffer{a: &bufA, b: &bufB} {
+		al.RemoveLogger(AdapterConsole)
+		h := slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+		if err := al.AddAdapter(AdapterSlog, NewSlogAdapter(slog.New(h))); err != nil {
+			t.Fatal(err)
+		}
+		defer al.Close()
+	}
+	a.Log(LevelWarning, "to a", String("k", "v"), Int("n", 1))
+	b.Debug("to b")
+
+	if s := bufA.String(); !strings.Contains(s, "level=WARN") || !strings.Contains(s, `msg="to a"`) ||
+		!strings.Contains(s, "k=v n=1") || strings.Contains(s, "to b") {
+		t.Errorf("a got %q", s)
+	}
+	if s := bufB.String(); !strings.Contains(s, "level=DEBUG") || !strings.Contains(s, `msg="to b"`) || strings.Contains(s, "to a") {
+		t.Errorf("b got %q", s)
+	}
+}
+
+func TestSlogAdapterLevel(t *testing.T) {
+	var buf bytes.Buffer
+	lg := NewSlogAdapter(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	if err := lg.Init(`{"level":1}`); err != nil {
+		t.Fatal(err)
+	}
+	lg.WriteMsg(time.Now(), "info", LevelInfo)
+	lg.WriteMsg(time.Now(), "error", LevelError)
+	lg.WriteMsg(time.Now(), "unknown", 7)
+	if s := buf.String(); strings.Contains(s, `"info"`) || !strings.Contains(s, `"level":"ERROR","msg":"error"`) {
+		t.Errorf("got %q", s)
+	}
+
+	if err := NewSlogAdapter(nil).Init(`{}`); err == nil {
+		t.Error("Init succeeded without a slog logger")
+	}
+}
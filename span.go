@@ -0,0 +1,65 @@
+package logs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Span 一次操作从开始到结束的两条log，带着同样的 span_id，结束时带上耗时
+type Span struct {
+	al    *AppLogger
+	name  string
+	id    string
+	start time.Time
+	ended int32
+}
+
+// crypto/rand 不可用时生成 span ID 用的计数器
+var spanCounter uint64
+
+// Span 以 Info 级别写出 "span start" 并返回 Span，之后调用 End 或 Fail 写出结束的log，
+// 两条log都带有 span=name 和同一个 span_id
+func (al *AppLogger) Span(name string) *Span {
+	s := &Span{al: al, name: name, id: newSpanID(), start: time.Now()}
+	if al.Enabled(LevelInfo) {
+		al.writeMsg(LevelInfo, s.fields(), "span start")
+	}
+	return s
+}
+
+// ID 返回 span 的 ID
+func (s *Span) ID() string {
+	return s.id
+}
+
+// End 以 Info 级别写出 "span end"，带上耗时 duration；End 和 Fail 只有第一次调用有效
+func (s *Span) End() {
+	if !atomic.CompareAndSwapInt32(&s.ended, 0, 1) || !s.al.Enabled(LevelInfo) {
+		return
+	}
+	s.al.writeMsg(LevelInfo, append(s.fields(), Duration("duration", time.Since(s.start))), "span end")
+}
+
+// Fail 以 Error 级别写出 "span failed"，带上耗时 duration 和 err
+func (s *Span) Fail(err error) {
+	if !atomic.CompareAndSwapInt32(&s.ended, 0, 1) || !s.al.Enabled(LevelError) {
+		return
+	}
+	s.al.writeMsg(LevelError, append(s.fields(), Duration("duration", time.Since(s.start)), Err(err)), "span failed")
+}
+
+func (s *Span) fields() []Field {
+	return []Field{String("span", s.name), String("span_id", s.id)}
+}
+
+// newSpanID 返回16位十六进制的随机 ID
+func newSpanID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatUint(atomic.AddUint64(&spanCounter, 1), 16)
+	}
+	return hex.EncodeToString(b)
+}
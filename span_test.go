@@ -0,0 +1,70 @@
+package logs
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSpan(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	s := al.Span("load")
+	if len(s.ID()) != 16 {
+		t.Errorf("span id %q", s.ID())
+	}
+	s.End()
+	s.Fail(errors.New("ignored after End"))
+
+	f := al.Span("save")
+	f.Fail(errors.New("disk full"))
+	f.End()
+
+	records := c.all()
+	if len(records) != 4 {
+		t.Fatalf("got %q", c.lines())
+	}
+	want := []struct {
+		level int
+		msg   string
+		span  string
+	}{
+		{LevelInfo, "span start", "load"},
+		{LevelInfo, "span end", "load"},
+		{LevelInfo, "span start", "save"},
+		{LevelError, "span failed", "save"},
+	}
+	for i, w := range want {
+		r := records[i]
+		if r.Level != w.level || r.Msg != w.msg || r.Fields["span"] != w.span {
+			t.Errorf("record %d = %+v", i, r)
+		}
+	}
+	if records[0].Fields["span_id"] != s.ID() || records[1].Fields["span_id"] != s.ID() {
+		t.Errorf("span_id not carried: %v, %v", records[0].Fields, records[1].Fields)
+	}
+	if records[2].Fields["span_id"] == s.ID() {
+		t.Error("two spans share an id")
+	}
+	if _, ok := records[1].Fields["duration"].(time.Duration); !ok {
+		t.Errorf("no duration on end: %v", records[1].Fields)
+	}
+	if records[3].Fields["error"] != "disk full" {
+		t.Errorf("fields %v", records[3].Fields)
+	}
+}
+
+func TestSpanDisabled(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.SetLevel(LevelWarning)
+	s := al.Span("quiet")
+	s.End()
+	if n := len(c.all()); n != 0 {
+		t.Errorf("%d records below the level", n)
+	}
+	al.Span("loud").Fail(nil)
+	if r := c.all(); len(r) != 1 || r[0].Msg != "span failed" {
+		t.Errorf("got %q", c.lines())
+	}
+}
@@ -0,0 +1,211 @@
+package logs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// AdapterStackdriver 写到 Google Cloud Logging
+const AdapterStackdriver = "stackdriver"
+
+// 缺省的批次大小
+const defaultStackdriverBatchSize = 100
+
+// stackdriverSeverities 各级别对应的 Cloud Logging severity
+var stackdriverSeverities = [LevelDebug + 1]string{
+	LevelError:   "ERROR",
+	LevelWarning: "WARNING",
+	LevelInfo:    "INFO",
+	LevelDebug:   "DEBUG",
+}
+
+// stackdriverSeverity 返回 level 对应的 severity，未知的级别为 "DEFAULT"
+func stackdriverSeverity(level int) string {
+	if !validLevel(level) {
+		return "DEFAULT"
+	}
+	return stackdriverSeverities[level]
+}
+
+// StackdriverEntry 一条 Cloud Logging 的结构化 log entry
+type StackdriverEntry struct {
+	Severity    string
+	Timestamp   time.Time
+	JSONPayload map[string]interface{} // 包括 message 和所有字段
+}
+
+// StackdriverClient 发送 log entry 的客户端，由使用者用 cloud.google.com/go/logging 等实现，避免本包直接依赖 SDK
+type StackdriverClient interface {
+	WriteEntries(logName string, entries []StackdriverEntry) error
+}
+
+var (
+	stackdriverClientLock sync.Mutex
+	stackdriverClient     StackdriverClient
+)
+
+// SetStackdriverClient 设置之后通过 AddLogger 创建的 stackdriver adapter 使用的客户端；
+// 多个 AppLogger 要用不同的客户端时用 NewStackdriverAdapter
+func SetStackdriverClient(c StackdriverClient) {
+	stackdriverClientLock.Lock()
+	stackdriverClient = c
+	stackdriverClientLock.Unlock()
+}
+
+// stackdriverWriter 把 log 攒成批次后用 WriteEntries 发送，Flush 时强制发送
+type stackdriverWriter struct {
+	sync.Mutex
+	client  StackdriverClient
+	entries []StackdriverEntry
+	err     error // 发送失败的错误，下一次写入时返回，Flush 之后由 AppLogger 通过 takeErr 取出
+
+	LogName   string       `json:"log_name"`
+	BatchSize int          `json:"batch_size"`
+	Level     adapterLevel `json:"level"`
+}
+
+// NewStackdriver create a stackdriver writer using the client set by SetStackdriverClient.
+func NewStackdriver() Logger {
+	stackdriverClientLock.Lock()
+	defer stackdriverClientLock.Unlock()
+	return NewStackdriverAdapter(stackdriverClient)
+}
+
+// NewStackdriverAdapter 返回使用 c 发送的 stackdriver adapter，不受 SetStackdriverClient 影响；
+// 先用 Init 设置 log_name，配置同 AddLogger，再用 AddAdapter 添加
+func NewStackdriverAdapter(c StackdriverClient) Logger {
+	return &stackdriverWriter{
+		client:    c,
+		BatchSize: defaultStackdriverBatchSize,
+		Level:     LevelDebug,
+	}
+}
+
+// Init init stackdriver writer.
+// jsonConfig like '{"log_name":"app","batch_size":100,"level":2}'.
+func (s *stackdriverWriter) Init(jsonConfig string) error {
+	if len(jsonConfig) > 0 {
+		if err := json.Unmarshal([]byte(jsonConfig), s); err != nil {
+			return err
+		}
+	}
+	if err := s.checkConfig(); err != nil {
+		return err
+	}
+	if s.BatchSize <= 0 {
+		s.BatchSize = defaultStackdriverBatchSize
+	}
+	return nil
+}
+
+// checkConfig 检查客户端和配置的取值
+func (s *stackdriverWriter) checkConfig() error {
+	if s.client == nil {
+		return errors.New("logs: stackdriver client is nil (forgotten SetStackdriverClient?)")
+	}
+	if s.LogName == "" {
+		return errors.New("logs: stackdriver log_name is required")
+	}
+	return nil
+}
+
+// WriteMsg add message to the current batch as a payload with only the message.
+func (s *stackdriverWriter) WriteMsg(when time.Time, msg string, level int) error {
+	if level > s.Level.get() {
+		return nil
+	}
+	return s.add(StackdriverEntry{
+		Severity:    stackdriverSeverity(level),
+		Timestamp:   when,
+		JSONPayload: map[string]interface{}{"message": msg},
+	})
+}
+
+// WriteRecord add record to the current batch, with its fields in the payload.
+func (s *stackdriverWriter) WriteRecord(r *Record) error {
+	if r.Level > s.Level.get() {
+		return nil
+	}
+	payload := make(map[string]interface{}, len(r.Fields)+3)
+	for k, v := range r.Fields {
+		payload[k] = v
+	}
+	payload["message"] = r.Msg
+	if r.Prefix != "" {
+		payload["prefix"] = r.Prefix
+	}
+	if r.File != "" {
+		location := map[string]interface{}{"file": r.File, "line": r.Line}
+		if r.Func != "" {
+			location["function"] = r.Func
+		}
+		payload["logging.googleapis.com/sourceLocation"] = location
+	}
+	severity := stackdriverSeverity(r.Level)
+	if r.noLevel {
+		severity = "DEFAULT"
+	}
+	return s.add(StackdriverEntry{Severity: severity, Timestamp: r.When, JSONPayload: payload})
+}
+
+// add 把 entry 放进当前批次，满了就发送
+func (s *stackdriverWriter) add(entry StackdriverEntry) error {
+	s.Lock()
+	defer s.Unlock()
+	s.entries = append(s.entries, entry)
+	if len(s.entries) >= s.BatchSize {
+		s.send()
+	}
+	err := s.err
+	s.err = nil
+	return err
+}
+
+// send 发送当前批次；调用时需持有锁
+func (s *stackdriverWriter) send() {
+	if len(s.entries) == 0 {
+		return
+	}
+	if err := s.client.WriteEntries(s.LogName, s.entries); err != nil {
+		s.err = fmt.Errorf("logs: stackdriver WriteEntries dropped %d entries: %v", len(s.entries), err)
+	}
+	s.entries = nil
+}
+
+// takeErr 返回并清除发送失败的错误，用于报告 Flush 和 Destroy 里发送的最后一批
+func (s *stackdriverWriter) takeErr() error {
+	s.Lock()
+	defer s.Unlock()
+	err := s.err
+	s.err = nil
+	return err
+}
+
+// GetLevel return the level of this writer.
+func (s *stackdriverWriter) GetLevel() int {
+	return s.Level.get()
+}
+
+// SetLevel set the level of this writer.
+func (s *stackdriverWriter) SetLevel(level int) {
+	s.Level.set(level)
+}
+
+// Destroy send the remaining entries.
+func (s *stackdriverWriter) Destroy() {
+	s.Flush()
+}
+
+// Flush send the current batch.
+func (s *stackdriverWriter) Flush() {
+	s.Lock()
+	s.send()
+	s.Unlock()
+}
+
+func init() {
+	Register(AdapterStackdriver, NewStackdriver)
+}
@@ -0,0 +1,82 @@
+package logs
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewStackdriverAdapter(t *testing.T) {
+	clients := []*fakeStackdriver{{}, {}}
+	loggers := make([]*AppLogger, len(clients))
+	for i, c := range clients {
+		lg := NewStackdriverAdapter(c)
+		if err := lg.Init(`{"log_name":"app"}`); err != nil {
+			t.Fatal(err)
+		}
+		al := NewAppLogger()
+		al.RemoveLogger(AdapterConsole)
+		if err := al.AddAdapter(AdapterStackdriver, lg); err != nil {
+			t.Fatal(err)
+		}
+		loggers[i] = al
+	}
+	loggers[0].Log(LevelWarning, "first", String("k", "v"))
+	loggers[1].Info("second")
+	loggers[1].Info("third")
+	for _, al := range loggers {
+		al.Close()
+	}
+	if n := len(clients[0].entries); n != 1 {
+		t.Fatalf("client 0 got %d entries", n)
+	}
+	e := clients[0].entries[0]
+	if e.Severity != "WARNING" || e.JSONPayload["message"] != "first" || e.JSONPayload["k"] != "v" {
+		t.Errorf("got %+v", e)
+	}
+	if n := len(clients[1].entries); n != 2 {
+		t.Errorf("client 1 got %d entries", n)
+	}
+}
+
+func TestStackdriverAdapterInit(t *testing.T) {
+	if err := NewStackdriverAdapter(nil).Init(`{"log_name":"app"}`); err == nil {
+		t.Error("Init succeeded without a client")
+	}
+	if err := NewStackdriverAdapter(&fakeStackdriver{}).Init(`{}`); err == nil {
+		t.Error("Init succeeded without a log_name")
+	}
+}
+
+// 报告 Flush 和 Close 时发送的最后一批的错误
+func TestStackdriverFinalBatchError(t *testing.T) {
+	client := &fakeStackdriver{}
+	client.setErr(errors.New("quota exceeded"))
+	lg := NewStackdriverAdapter(client)
+	if err := lg.Init(`{"log_name":"app"}`); err != nil {
+		t.Fatal(err)
+	}
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	al.AddAdapter(AdapterStackdriver, lg)
+	al.SetAuditMode(true)
+	var reported []error
+	al.SetErrorHandler(func(name string, err error) {
+		reported = append(reported, err)
+	})
+
+	al.Info("flushed")
+	if err := al.FlushAdapter(AdapterStackdriver); err == nil || !strings.Contains(err.Error(), "dropped 1 entries: quota exceeded") {
+		t.Errorf("FlushAdapter() = %v", err)
+	}
+	if _, name, err := al.LastError(); name != AdapterStackdriver || err == nil {
+		t.Errorf("LastError() = %q, %v", name, err)
+	}
+	al.Info("closed")
+	if err := al.CloseErr(); err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Errorf("CloseErr() = %v", err)
+	}
+	if len(reported) != 2 {
+		t.Errorf("reported %v", reported)
+	}
+}
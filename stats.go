@@ -0,0 +1,94 @@
+package logs
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// runtimeStats StartRuntimeStats 启动的所有 goroutine，Close 时全部停止
+type runtimeStats struct {
+	sync.Mutex
+	level  int32 // SetRuntimeStatsLevel 的级别 + 1，0 表示使用 LevelInfo
+	closed bool
+	stops  map[chan struct{}]chan struct{} // 每个 goroutine 的停止 channel 和退出时关闭的 channel
+}
+
+// StartRuntimeStats 每隔 interval 写一条 "runtime stats" log，字段包括堆内存、goroutine 数量和 GC 次数、停顿时间，
+// 级别由 SetRuntimeStatsLevel 设置，缺省为 Info。返回的函数停止这个 goroutine，返回之后不会再写出；Close 时自动停止。
+// 每次会调用 runtime.ReadMemStats，它会短暂地暂停所有 goroutine，interval 不宜太小
+func (al *AppLogger) StartRuntimeStats(interval time.Duration) (cancel func()) {
+	s := &al.stats
+	s.Lock()
+	defer s.Unlock()
+	if s.closed || interval <= 0 {
+		return func() {}
+	}
+	if s.stops == nil {
+		s.stops = make(map[chan struct{}]chan struct{})
+	}
+	stop, done := make(chan struct{}), make(chan struct{})
+	s.stops[stop] = done
+	go al.reportRuntimeStats(interval, stop, done)
+	return func() {
+		s.Lock()
+		_, running := s.stops[stop]
+		delete(s.stops, stop)
+		s.Unlock()
+		if running {
+			close(stop)
+		}
+		<-done
+	}
+}
+
+// SetRuntimeStatsLevel 设置 StartRuntimeStats 写log的级别
+func (al *AppLogger) SetRuntimeStatsLevel(level int) {
+	atomic.StoreInt32(&al.stats.level, int32(level)+1)
+}
+
+func (al *AppLogger) reportRuntimeStats(interval time.Duration, stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+		level := LevelInfo
+		if l := atomic.LoadInt32(&al.stats.level); l != 0 {
+			level = int(l) - 1
+		}
+		if !al.Enabled(level) {
+			continue
+		}
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		al.Log(level, "runtime stats",
+			Any("heap_alloc", m.HeapAlloc),
+			Any("heap_inuse", m.HeapInuse),
+			Any("heap_objects", m.HeapObjects),
+			Any("sys", m.Sys),
+			Int("goroutines", runtime.NumGoroutine()),
+			Any("num_gc", m.NumGC),
+			Duration("gc_pause_last", time.Duration(m.PauseNs[(m.NumGC+255)%256])),
+			Duration("gc_pause_total", time.Duration(m.PauseTotalNs)),
+		)
+	}
+}
+
+// stop 停止所有 StartRuntimeStats 启动的 goroutine，之后再调用 StartRuntimeStats 不会启动
+func (s *runtimeStats) stop() {
+	s.Lock()
+	s.closed = true
+	stops := s.stops
+	s.stops = nil
+	s.Unlock()
+	for stop, done := range stops {
+		close(stop)
+		<-done
+	}
+}
@@ -0,0 +1,78 @@
+package logs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRuntimeStatsFields(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	cancel := al.StartRuntimeStats(5 * time.Millisecond)
+	deadline := time.Now().Add(2 * time.Second)
+	for len(c.all()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	records := c.all()
+	if len(records) == 0 {
+		t.Fatal("no runtime stats written")
+	}
+	r := records[0]
+	if r.Msg != "runtime stats" || r.Level != LevelInfo {
+		t.Errorf("got %q at level %d", r.Msg, r.Level)
+	}
+	for _, k := range []string{"heap_alloc", "heap_inuse", "heap_objects", "sys", "goroutines", "num_gc", "gc_pause_last", "gc_pause_total"} {
+		if _, ok := r.Fields[k]; !ok {
+			t.Errorf("missing field %q in %v", k, r.Fields)
+		}
+	}
+
+	// cancel 返回之后不再写出
+	n := len(c.all())
+	time.Sleep(20 * time.Millisecond)
+	if got := len(c.all()); got != n {
+		t.Errorf("%d stats written after cancel", got-n)
+	}
+}
+
+func TestRuntimeStatsLevel(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.SetLevel(LevelInfo)
+	al.SetRuntimeStatsLevel(LevelDebug)
+	cancel := al.StartRuntimeStats(2 * time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	if n := len(c.all()); n != 0 {
+		t.Errorf("%d stats written below the logger level", n)
+	}
+}
+
+func TestRuntimeStatsStopOnClose(t *testing.T) {
+	al, c := newTestLogger(t)
+	al.StartRuntimeStats(time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	within(t, 2*time.Second, "Close", al.Close)
+	n := len(c.all())
+	time.Sleep(10 * time.Millisecond)
+	if got := len(c.all()); got != n {
+		t.Errorf("%d stats written after Close", got-n)
+	}
+	// Close 之后不再启动
+	al.StartRuntimeStats(time.Millisecond)()
+}
+
+func TestRuntimeStatsCloseWhileDrained(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		al, _ := newTestLogger(t)
+		if async {
+			al.Async()
+		}
+		al.StartRuntimeStats(time.Millisecond)
+		al.Drain()
+		// 让 goroutine 阻塞在写log上
+		time.Sleep(10 * time.Millisecond)
+		within(t, 2*time.Second, "Close after Drain", al.Close)
+	}
+}
@@ -0,0 +1,189 @@
+package logs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AdapterTee 把log同时写到两个子 Logger，两个子 Logger 各自有级别
+const AdapterTee = "tee"
+
+// teeSink tee 的一个子 Logger 的配置
+type teeSink struct {
+	Adapter string          `json:"adapter"`
+	Level   int             `json:"level"` // 缺省为 LevelDebug，即写所有级别
+	Config  json.RawMessage `json:"config"`
+
+	lg Logger
+}
+
+// UnmarshalJSON 没有配置 level 时使用 LevelDebug
+func (s *teeSink) UnmarshalJSON(b []byte) error {
+	type plain teeSink
+	p := plain{Level: LevelDebug}
+	if err := json.Unmarshal(b, &p); err != nil {
+		return err
+	}
+	*s = teeSink(p)
+	return nil
+}
+
+// teeWriter 把每条log写给 First 和 Second 中级别允许的那些
+type teeWriter struct {
+	First  *teeSink `json:"first"`
+	Second *teeSink `json:"second"`
+}
+
+// NewTee create a tee writer.
+func NewTee() Logger {
+	return &teeWriter{}
+}
+
+// Init init the two child writers.
+// jsonConfig like '{"first":{"adapter":"file","level":3,"config":{"filename":"app.log"}},"second":{"adapter":"console","level":1}}'.
+func (t *teeWriter) Init(jsonConfig string) error {
+	if len(jsonConfig) > 0 {
+		if err := json.Unmarshal([]byte(jsonConfig), t); err != nil {
+			return err
+		}
+	}
+	if t.First == nil || t.Second == nil {
+		return errors.New("logs: tee needs both first and second")
+	}
+	for _, sink := range t.sinks() {
+		newLogger, ok := adapters[sink.Adapter]
+		if !ok {
+			return fmt.Errorf("logs: unknown adaptername %q in tee", sink.Adapter)
+		}
+		sink.lg = newLogger()
+		config := "{}"
+		if len(sink.Config) > 0 {
+			config = string(sink.Config)
+		}
+		if err := sink.lg.Init(config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *teeWriter) sinks() []*teeSink {
+	return []*teeSink{t.First, t.Second}
+}
+
+// WriteMsg write message to the children whose level permits it.
+func (t *teeWriter) WriteMsg(when time.Time, msg string, level int) error {
+	var errs []error
+	for _, sink := range t.sinks() {
+		if level > sink.Level {
+			continue
+		}
+		if err := sink.lg.WriteMsg(when, msg, level); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", sink.Adapter, err))
+		}
+	}
+	return teeError(errs)
+}
+
+// WriteRecord write record to the children whose level permits it.
+func (t *teeWriter) WriteRecord(r *Record) error {
+	var errs []error
+	for _, sink := range t.sinks() {
+		if r.Level > sink.Level {
+			continue
+		}
+		var err error
+		if rw, ok := sink.lg.(RecordWriter); ok {
+			err = rw.WriteRecord(r)
+		} else {
+			err = sink.lg.WriteMsg(r.When, r.String(), r.Level)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", sink.Adapter, err))
+		}
+	}
+	return teeError(errs)
+}
+
+// WriteRaw write the bytes produced by the AppLogger's Formatter to the children whose level permits it.
+func (t *teeWriter) WriteRaw(p []byte, level int) error {
+	var errs []error
+	for _, sink := range t.sinks() {
+		if level > sink.Level {
+			continue
+		}
+		var err error
+		if rw, ok := sink.lg.(RawWriter); ok {
+			err = rw.WriteRaw(p, level)
+		} else {
+			err = sink.lg.WriteMsg(time.Now(), strings.TrimSuffix(string(p), "\n"), level)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", sink.Adapter, err))
+		}
+	}
+	return teeError(errs)
+}
+
+func teeError(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return fmt.Errorf("%v; %v", errs[0], errs[1])
+}
+
+// Destroy destroy both children.
+func (t *teeWriter) Destroy() {
+	for _, sink := range t.sinks() {
+		if sink != nil && sink.lg != nil {
+			sink.lg.Destroy()
+		}
+	}
+}
+
+// Flush flush both children.
+func (t *teeWriter) Flush() {
+	for _, sink := range t.sinks() {
+		if sink != nil && sink.lg != nil {
+			sink.lg.Flush()
+		}
+	}
+}
+
+// takeErr 取出两个 Logger 在 Flush 或 Destroy 里发送批次失败的错误
+func (t *teeWriter) takeErr() error {
+	var errs []error
+	for _, sink := range t.sinks() {
+		if sink == nil {
+			continue
+		}
+		if b, ok := sink.lg.(batchErrorer); ok {
+			if err := b.takeErr(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %v", sink.Adapter, err))
+			}
+		}
+	}
+	return teeError(errs)
+}
+
+// setPalette 把配色传给两个 Logger
+func (t *teeWriter) setPalette(p *palette) {
+	for _, sink := range t.sinks() {
+		if sink == nil {
+			continue
+		}
+		if themed, ok := sink.lg.(themedLogger); ok {
+			themed.setPalette(p)
+		}
+	}
+}
+
+func init() {
+	Register(AdapterTee, NewTee)
+}
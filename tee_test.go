@@ -0,0 +1,59 @@
+package logs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTeeLevels(t *testing.T) {
+	inTempDir(t)
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	err := al.AddLogger(AdapterTee, `{
+		"first":{"adapter":"file","level":3,"config":{"filename":"all.log","color":false}},
+		"second":{"adapter":"file","level":0,"config":{"filename":"errors.log","color":false}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	al.Error("failed")
+	al.Debug("details")
+	al.Close()
+
+	if got := readFile(t, "all.log"); !strings.Contains(got, "failed") || !strings.Contains(got, "details") {
+		t.Errorf("all.log got %q", got)
+	}
+	if got := readFile(t, "errors.log"); !strings.Contains(got, "failed") || strings.Contains(got, "details") {
+		t.Errorf("errors.log got %q", got)
+	}
+}
+
+// 没有配置 level 的子 Logger 写所有级别
+func TestTeeDefaultLevel(t *testing.T) {
+	inTempDir(t)
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	err := al.AddLogger(AdapterTee, `{
+		"first":{"adapter":"file","config":{"filename":"all.log","color":false}},
+		"second":{"adapter":"file","level":0,"config":{"filename":"errors.log","color":false}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	al.Debug("details")
+	al.Close()
+
+	if got := readFile(t, "all.log"); !strings.Contains(got, "details") {
+		t.Errorf("all.log got %q", got)
+	}
+	if got := readFile(t, "errors.log"); strings.Contains(got, "details") {
+		t.Errorf("errors.log got %q", got)
+	}
+}
+
+func TestTeeInit(t *testing.T) {
+	if err := NewTee().Init(`{"first":{"adapter":"console"}}`); err == nil {
+		t.Error("accepted a tee without second")
+	}
+	if err := NewTee().Init(`{"first":{"adapter":"console"},"second":{"adapter":"nope"}}`); err == nil {
+		t.Error("accepted an unknown child adapter")
+	}
+}
@@ -0,0 +1,47 @@
+package logs
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimer(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.EnableFuncCallDepth(true)
+
+	done := al.Timer("work")
+	time.Sleep(20 * time.Millisecond)
+	done()
+	done()
+
+	records := c.all()
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+	r := records[0]
+	if r.Level != LevelDebug || !strings.HasPrefix(r.Msg, "work took ") {
+		t.Fatalf("got %q at level %d", r.Msg, r.Level)
+	}
+	d, err := time.ParseDuration(strings.TrimPrefix(r.Msg, "work took "))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d < 20*time.Millisecond || d > 2*time.Second {
+		t.Errorf("took %v, want about 20ms", d)
+	}
+	if r.File != "timer_test.go" {
+		t.Errorf("caller %s:%d, want timer_test.go", r.File, r.Line)
+	}
+}
+
+func TestTimerDisabled(t *testing.T) {
+	al, c := newTestLogger(t)
+	defer al.Close()
+	al.SetLevel(LevelInfo)
+	al.Timer("work")()
+	if n := len(c.all()); n != 0 {
+		t.Errorf("%d records below the level", n)
+	}
+}
@@ -0,0 +1,230 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// AdapterUnixSocket 写到本机的 Unix domain socket
+const AdapterUnixSocket = "unixsocket"
+
+// unixSocketWriter 连接到 Path 指定的 Unix domain socket，每行一条log，写入失败时重新连接
+type unixSocketWriter struct {
+	sync.Mutex
+	conn    net.Conn
+	lg      *logWriter
+	pending []pendingLine // 连接断开期间保留的log
+	dropped int           // 上次返回错误之后因为缓冲区满丢弃的条数
+
+	Path  string       `json:"path"`
+	Level adapterLevel `json:"level"`
+	// Framing 为 "length" 时每条log前面加4字节大端序的长度，而不是以换行分隔
+	Framing string `json:"framing"`
+	// BufferSize 大于 0 时，连接断开期间最多保留这么多条log，重新连接之后按顺序补发；
+	// 缓冲区满时按 Drop 丢弃最旧（"oldest"，缺省）或最新（"newest"）的log
+	BufferSize int    `json:"buffer_size"`
+	Drop       string `json:"drop"`
+}
+
+// pendingLine 一条等待补发的log
+type pendingLine struct {
+	when time.Time
+	msg  string
+	raw  []byte // Formatter 的结果，不为 nil 时原样写出，不再加时间头
+}
+
+// 缓冲区满时的丢弃策略
+const (
+	DropOldest = "oldest"
+	DropNewest = "newest"
+)
+
+// 分帧方式
+const (
+	FramingNewline = "newline"
+	FramingLength  = "length"
+)
+
+// NewUnixSocket create a unix socket writer.
+func NewUnixSocket() Logger {
+	return &unixSocketWriter{Level: LevelDebug}
+}
+
+// Init init unix socket writer and connect to the socket.
+// jsonConfig like '{"path":"/run/log.sock","level":2}'.
+func (u *unixSocketWriter) Init(jsonConfig string) error {
+	if len(jsonConfig) > 0 {
+		if err := json.Unmarshal([]byte(jsonConfig), u); err != nil {
+			return err
+		}
+	}
+	if err := u.checkConfig(); err != nil {
+		return err
+	}
+	u.Lock()
+	defer u.Unlock()
+	if err := u.connect(); err != nil && u.BufferSize <= 0 {
+		return err
+	}
+	// 开启缓冲时 socket 暂时不可用也可以先保留log，等连接上之后再补发
+	return nil
+}
+
+// checkConfig 检查配置的取值，不连接
+func (u *unixSocketWriter) checkConfig() error {
+	if u.Path == "" {
+		return errors.New("logs: unixsocket path is required")
+	}
+	if u.Framing != "" && u.Framing != FramingNewline && u.Framing != FramingLength {
+		return fmt.Errorf("logs: unknown framing %q", u.Framing)
+	}
+	if u.Drop != "" && u.Drop != DropOldest && u.Drop != DropNewest {
+		return fmt.Errorf("logs: unknown drop policy %q", u.Drop)
+	}
+	return nil
+}
+
+// connect 建立连接，调用时需持有锁
+func (u *unixSocketWriter) connect() error {
+	if u.conn != nil {
+		u.conn.Close()
+		u.conn = nil
+	}
+	conn, err := net.Dial("unix", u.Path)
+	if err != nil {
+		return err
+	}
+	u.conn = conn
+	u.lg = newLogWriter(conn)
+	u.lg.lengthPrefix = u.Framing == FramingLength
+	return nil
+}
+
+// WriteMsg write message to the socket, reconnecting once if the connection is broken.
+// When buffering is enabled, messages are kept during an outage and resent in order after reconnecting.
+func (u *unixSocketWriter) WriteMsg(when time.Time, msg string, level int) error {
+	if level > u.Level.get() {
+		return nil
+	}
+	return u.writeLine(pendingLine{when: when, msg: msg})
+}
+
+// WriteRaw write the bytes produced by the AppLogger's Formatter, buffered like WriteMsg.
+func (u *unixSocketWriter) WriteRaw(p []byte, level int) error {
+	if level > u.Level.get() {
+		return nil
+	}
+	return u.writeLine(pendingLine{raw: append([]byte(nil), p...)})
+}
+
+// writeLine 同 WriteMsg，不检查级别
+func (u *unixSocketWriter) writeLine(line pendingLine) error {
+	u.Lock()
+	defer u.Unlock()
+	if u.BufferSize > 0 {
+		u.sendPending()
+		if len(u.pending) > 0 || u.send(line) != nil {
+			u.buffer(line)
+		}
+		if u.dropped > 0 {
+			err := fmt.Errorf("logs: unixsocket buffer full, dropped %d messages", u.dropped)
+			u.dropped = 0
+			return err
+		}
+		return nil
+	}
+	return u.send(line)
+}
+
+// send 写出一条log，连接断开时重新连接一次；调用时需持有锁
+func (u *unixSocketWriter) send(line pendingLine) error {
+	if u.conn != nil {
+		if err := u.write(line); err == nil {
+			return nil
+		}
+	}
+	if err := u.connect(); err != nil {
+		return err
+	}
+	return u.write(line)
+}
+
+// write 按分帧方式写出一条log；调用时需持有锁
+func (u *unixSocketWriter) write(line pendingLine) error {
+	if line.raw == nil {
+		_, err := u.lg.writeln(line.when, line.msg)
+		return err
+	}
+	p := line.raw
+	if u.lg.lengthPrefix {
+		p = u.lg.frame(bytes.TrimSuffix(p, []byte("\n")))
+	}
+	_, err := u.lg.writeRaw(p)
+	return err
+}
+
+// buffer 把 line 放进缓冲区，满了按 Drop 丢弃；调用时需持有锁
+func (u *unixSocketWriter) buffer(line pendingLine) {
+	if len(u.pending) >= u.BufferSize {
+		u.dropped++
+		if u.Drop == DropNewest {
+			return
+		}
+		copy(u.pending, u.pending[1:])
+		u.pending = u.pending[:len(u.pending)-1]
+	}
+	u.pending = append(u.pending, line)
+}
+
+// sendPending 按顺序补发缓冲区里的log，遇到写不出去的就停下，剩下的留到下次；调用时需持有锁
+func (u *unixSocketWriter) sendPending() {
+	sent := 0
+	for _, line := range u.pending {
+		if u.send(line) != nil {
+			break
+		}
+		sent++
+	}
+	n := copy(u.pending, u.pending[sent:])
+	for i := n; i < len(u.pending); i++ {
+		u.pending[i] = pendingLine{}
+	}
+	u.pending = u.pending[:n]
+}
+
+// GetLevel return the level of this writer.
+func (u *unixSocketWriter) GetLevel() int {
+	return u.Level.get()
+}
+
+// SetLevel set the level of this writer.
+func (u *unixSocketWriter) SetLevel(level int) {
+	u.Level.set(level)
+}
+
+// Destroy resend the buffered messages if possible and close the connection.
+func (u *unixSocketWriter) Destroy() {
+	u.Lock()
+	defer u.Unlock()
+	u.sendPending()
+	if u.conn != nil {
+		u.conn.Close()
+		u.conn = nil
+	}
+}
+
+// Flush resend the buffered messages if the socket is reachable.
+func (u *unixSocketWriter) Flush() {
+	u.Lock()
+	u.sendPending()
+	u.Unlock()
+}
+
+func init() {
+	Register(AdapterUnixSocket, NewUnixSocket)
+}
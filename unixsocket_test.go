@@ -0,0 +1,154 @@
+package logs
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// acceptLines 接受 ln 上的下一个连接，返回按行读取它的 Scanner
+func acceptLines(t *testing.T, ln net.Listener) (net.Conn, *bufio.Scanner) {
+	t.Helper()
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	return conn, bufio.NewScanner(conn)
+}
+
+func TestUnixSocket(t *testing.T) {
+	path, ln := listenUnix(t)
+	lg := NewUnixSocket()
+	if err := lg.Init(`{"path":"` + path + `","level":2}`); err != nil {
+		t.Fatal(err)
+	}
+	defer lg.Destroy()
+	conn, lines := acceptLines(t, ln)
+
+	lg.WriteMsg(time.Now(), "[D]  hidden", LevelDebug)
+	lg.WriteMsg(time.Now(), "[I]  one", LevelInfo)
+	if !lines.Scan() || !strings.HasSuffix(lines.Text(), "[I]  one") {
+		t.Fatalf("got %q, %v", lines.Text(), lines.Err())
+	}
+
+	// 连接断开后重新连接一次
+	conn.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, _ := ln.Accept()
+		accepted <- conn
+	}()
+	if err := lg.WriteMsg(time.Now(), "[I]  two", LevelInfo); err != nil {
+		t.Fatal(err)
+	}
+	if conn = <-accepted; conn == nil {
+		t.Fatal("no new connection")
+	}
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	lines = bufio.NewScanner(conn)
+	if !lines.Scan() || !strings.HasSuffix(lines.Text(), "[I]  two") {
+		t.Errorf("got %q, %v after reconnecting", lines.Text(), lines.Err())
+	}
+}
+
+func TestUnixSocketInit(t *testing.T) {
+	path, ln := listenUnix(t)
+	ln.Close()
+	if err := NewUnixSocket().Init(`{"path":"` + path + `"}`); err == nil {
+		t.Error("connected to a closed socket")
+	}
+	if err := NewUnixSocket().Init(`{}`); err == nil {
+		t.Error("accepted an empty path")
+	}
+}
+
+// 按长度分帧时消息里可以有换行
+func TestUnixSocketLengthFraming(t *testing.T) {
+	path, ln := listenUnix(t)
+	lg := NewUnixSocket()
+	if err := lg.Init(`{"path":"` + path + `","framing":"length"}`); err != nil {
+		t.Fatal(err)
+	}
+	conn, _ := acceptLines(t, ln)
+	msgs := []string{"[I]  first\nsecond line", "[E]  x"}
+	for _, m := range msgs {
+		lg.WriteMsg(time.Now(), m, LevelInfo)
+	}
+	lg.Destroy()
+
+	r := bufio.NewReader(conn)
+	for _, m := range msgs {
+		var size [4]byte
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			t.Fatal(err)
+		}
+		frame := make([]byte, binary.BigEndian.Uint32(size[:]))
+		if _, err := io.ReadFull(r, frame); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(string(frame), m) {
+			t.Errorf("frame %q, want suffix %q", frame, m)
+		}
+	}
+	if _, err := r.ReadByte(); err != io.EOF {
+		t.Errorf("trailing data after the frames: %v", err)
+	}
+}
+
+// 开启缓冲时连接不上的log先保留，连接上之后按顺序补发，超出的按 drop 丢弃
+func TestUnixSocketBuffer(t *testing.T) {
+	for _, tc := range []struct {
+		drop string
+		want []string
+	}{
+		{"", []string{"m2", "m3", "m4"}},
+		{DropNewest, []string{"m0", "m1", "m2"}},
+	} {
+		path, ln := listenUnix(t)
+		ln.Close()
+		lg := NewUnixSocket()
+		if err := lg.Init(`{"path":"` + path + `","buffer_size":3,"drop":"` + tc.drop + `"}`); err != nil {
+			t.Fatal(err)
+		}
+		var errs int
+		for i := 0; i < 5; i++ {
+			if err := lg.WriteMsg(time.Now(), fmt.Sprint("m", i), LevelInfo); err != nil {
+				errs++
+			}
+		}
+		if errs != 2 {
+			t.Errorf("drop %q: %d errors for 2 dropped messages", tc.drop, errs)
+		}
+
+		ln, err := net.Listen("unix", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lg.Flush()
+		lg.WriteMsg(time.Now(), "live", LevelInfo)
+		conn, lines := acceptLines(t, ln)
+		for _, w := range append(tc.want, "live") {
+			if !lines.Scan() || !strings.HasSuffix(lines.Text(), w) {
+				t.Errorf("drop %q: got %q, want suffix %q", tc.drop, lines.Text(), w)
+			}
+		}
+		lg.Destroy()
+		conn.Close()
+		ln.Close()
+	}
+}
+
+func TestUnixSocketBufferConfig(t *testing.T) {
+	if err := NewUnixSocket().Init(`{"path":"/nonexistent/s","buffer_size":1,"drop":"random"}`); err == nil {
+		t.Error("accepted an unknown drop policy")
+	}
+	if err := NewUnixSocket().Init(`{"path":"/nonexistent/s","buffer_size":1}`); err != nil {
+		t.Errorf("buffered Init failed while the socket is down: %v", err)
+	}
+}
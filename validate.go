@@ -0,0 +1,233 @@
+package logs
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+// 各 adapter 检查配置的函数，只解析配置并检查资源是否可用，不会创建、清空文件或者写出任何log；
+// 没有在这里的 adapter（如使用者自己 Register 的）通过 Init 再 Destroy 检查
+var configValidators = map[string]func(jsonConfig string) error{
+	AdapterFile:        validateFileConfig,
+	AdapterConsole:     validateConsoleConfig,
+	AdapterUnixSocket:  validateUnixSocketConfig,
+	AdapterDB:          validateDBConfig,
+	AdapterCloudWatch:  validateCloudWatchConfig,
+	AdapterStackdriver: validateStackdriverConfig,
+}
+
+func init() {
+	// tee 通过 ValidateConfig 检查子 Logger，放在上面的初始化表达式里会形成初始化循环
+	configValidators[AdapterTee] = validateTeeConfig
+}
+
+// ValidateConfig 检查 adapterName 的配置是否有效（级别是否合法、文件是否可写、地址能否连接等），
+// 但不会把它添加到任何 AppLogger，用于在启动时尽早发现配置错误
+func ValidateConfig(adapterName, jsonConfig string) error {
+	newLogger, ok := adapters[adapterType(adapterName)]
+	if !ok {
+		return fmt.Errorf("logs: unknown adaptername %q (forgotten Register?)", adapterName)
+	}
+	if validate, ok := configValidators[adapterType(adapterName)]; ok {
+		return validate(jsonConfig)
+	}
+
+	lg := newLogger()
+	defer lg.Destroy()
+	if err := lg.Init(jsonConfig); err != nil {
+		return err
+	}
+	if lw, ok := lg.(LevelWriter); ok {
+		return validateLevel(lw.GetLevel())
+	}
+	return nil
+}
+
+// decodeConfig 把 jsonConfig 解析到 v，jsonConfig 为空时保留 v 原来的值
+func decodeConfig(jsonConfig string, v interface{}) error {
+	if len(jsonConfig) == 0 {
+		return nil
+	}
+	return json.Unmarshal([]byte(jsonConfig), v)
+}
+
+func validateLevel(level int) error {
+	if level < LevelError || level > LevelDebug {
+		return fmt.Errorf("logs: invalid level %d", level)
+	}
+	return nil
+}
+
+// validateFileConfig 检查 file adapter 的配置，不会创建或清空日志文件
+func validateFileConfig(jsonConfig string) error {
+	f := &fileWriter{FileName: "default.log", Level: LevelDebug, Append: true}
+	if err := decodeConfig(jsonConfig, f); err != nil {
+		return err
+	}
+	if err := validateLevel(f.Level.get()); err != nil {
+		return err
+	}
+	if _, err := loadTimeZones(f.TimeZone, f.SecondTimeZone); err != nil {
+		return err
+	}
+	if err := checkWritable(f.FileName); err != nil {
+		return err
+	}
+	for name, filename := range f.Files {
+		if _, ok := levelNames[name]; !ok {
+			return fmt.Errorf("logs: unknown level %q in files config", name)
+		}
+		if err := checkWritable(filename); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateConsoleConfig 检查 console adapter 的配置
+func validateConsoleConfig(jsonConfig string) error {
+	c := NewConsole().(*consoleWriter)
+	if err := decodeConfig(jsonConfig, c); err != nil {
+		return err
+	}
+	if err := validateLevel(c.Level.get()); err != nil {
+		return err
+	}
+	if _, err := loadTimeZones(c.TimeZone, c.SecondTimeZone); err != nil {
+		return err
+	}
+	_, err := parseLevelAttrs(c.LevelAttrs)
+	return err
+}
+
+// validateTeeConfig 检查 tee adapter 和两个子 Logger 的配置，子 Logger 按各自的 adapter 检查，不会初始化
+func validateTeeConfig(jsonConfig string) error {
+	t := &teeWriter{}
+	if err := decodeConfig(jsonConfig, t); err != nil {
+		return err
+	}
+	if t.First == nil || t.Second == nil {
+		return errors.New("logs: tee needs both first and second")
+	}
+	for _, sink := range t.sinks() {
+		if _, ok := adapters[sink.Adapter]; !ok {
+			return fmt.Errorf("logs: unknown adaptername %q in tee", sink.Adapter)
+		}
+		if err := validateLevel(sink.Level); err != nil {
+			return fmt.Errorf("logs: tee %s: %v", sink.Adapter, err)
+		}
+		config := "{}"
+		if len(sink.Config) > 0 {
+			config = string(sink.Config)
+		}
+		if err := ValidateConfig(sink.Adapter, config); err != nil {
+			return fmt.Errorf("logs: tee %s: %v", sink.Adapter, err)
+		}
+	}
+	return nil
+}
+
+// validateUnixSocketConfig 检查 unixsocket adapter 的配置，没有开启缓冲时试着连接一次再断开
+func validateUnixSocketConfig(jsonConfig string) error {
+	u := NewUnixSocket().(*unixSocketWriter)
+	if err := decodeConfig(jsonConfig, u); err != nil {
+		return err
+	}
+	if err := u.checkConfig(); err != nil {
+		return err
+	}
+	if err := validateLevel(u.Level.get()); err != nil {
+		return err
+	}
+	if u.BufferSize > 0 {
+		return nil
+	}
+	conn, err := net.Dial("unix", u.Path)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// validateDBConfig 检查 db adapter 的配置，不会打开连接
+func validateDBConfig(jsonConfig string) error {
+	d := NewDB().(*dbWriter)
+	if err := decodeConfig(jsonConfig, d); err != nil {
+		return err
+	}
+	if !isSQLIdentifier(d.Table) {
+		return fmt.Errorf("logs: invalid db table name %q", d.Table)
+	}
+	if err := validateLevel(d.Level.get()); err != nil {
+		return err
+	}
+	if d.Driver != "" || d.DSN != "" {
+		for _, name := range sql.Drivers() {
+			if name == d.Driver {
+				return nil
+			}
+		}
+		return fmt.Errorf("logs: unknown db driver %q (forgotten import?)", d.Driver)
+	}
+	if d.db == nil {
+		return errors.New("logs: db handle is nil (forgotten SetDB or driver_dsn?)")
+	}
+	return nil
+}
+
+// validateCloudWatchConfig 检查 cloudwatch adapter 的配置
+func validateCloudWatchConfig(jsonConfig string) error {
+	c := NewCloudWatch().(*cloudWatchWriter)
+	if err := decodeConfig(jsonConfig, c); err != nil {
+		return err
+	}
+	if err := c.checkConfig(); err != nil {
+		return err
+	}
+	return validateLevel(c.Level.get())
+}
+
+// validateStackdriverConfig 检查 stackdriver adapter 的配置
+func validateStackdriverConfig(jsonConfig string) error {
+	s := NewStackdriver().(*stackdriverWriter)
+	if err := decodeConfig(jsonConfig, s); err != nil {
+		return err
+	}
+	if err := s.checkConfig(); err != nil {
+		return err
+	}
+	return validateLevel(s.Level.get())
+}
+
+// checkWritable 检查 filename 可写：已经存在时尝试以追加方式打开，不存在时检查所在目录能否创建文件
+func checkWritable(filename string) error {
+	if filename == "" {
+		return fmt.Errorf("logs: empty filename")
+	}
+	info, err := os.Stat(filename)
+	if err == nil {
+		if info.IsDir() {
+			return fmt.Errorf("logs: %s is a directory", filename)
+		}
+		fh, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			return err
+		}
+		return fh.Close()
+	}
+	if !os.IsNotExist(err) {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), ".logs-validate-")
+	if err != nil {
+		return fmt.Errorf("logs: cannot create %s: %v", filename, err)
+	}
+	tmp.Close()
+	return os.Remove(tmp.Name())
+}
@@ -0,0 +1,117 @@
+package logs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestValidateConfig(t *testing.T) {
+	dir := inTempDir(t)
+	sock, _ := listenUnix(t)
+	db, _ := openFakeDB(t, "validate")
+	SetDB(db)
+	SetCloudWatchClient(&fakeCloudWatch{})
+	SetStackdriverClient(&fakeStackdriver{})
+	defer SetDB(nil)
+	defer SetCloudWatchClient(nil)
+	defer SetStackdriverClient(nil)
+
+	tests := []struct {
+		adapter string
+		config  string
+		valid   bool
+	}{
+		{AdapterFile, `{"filename":"app.log"}`, true},
+		{AdapterFile, `{"filename":"app.log","level":7}`, false},
+		{AdapterFile, `{"filename":"` + filepath.Join(dir, "missing", "app.log") + `"}`, false},
+		{AdapterFile, `{"filename":"app.log","files":{"fatal":"x.log"}}`, false},
+		{AdapterFile, `{"filename":`, false},
+		{AdapterConsole, ``, true},
+		{AdapterConsole, `{"level":2,"time_zone":"UTC","level_attrs":{"error":"bold"}}`, true},
+		{AdapterConsole, `{"level":-1}`, false},
+		{AdapterConsole, `{"time_zone":"Nowhere/Nothing"}`, false},
+		{AdapterConsole, `{"level_attrs":{"error":"blink"}}`, false},
+		{AdapterTee, `{"first":{"adapter":"file","level":3,"config":{"filename":"tee.log"}},"second":{"adapter":"console","level":1}}`, true},
+		{AdapterTee, `{"first":{"adapter":"console"}}`, false},
+		{AdapterTee, `{"first":{"adapter":"nope"},"second":{"adapter":"console"}}`, false},
+		{AdapterTee, `{"first":{"adapter":"console","level":9},"second":{"adapter":"console"}}`, false},
+		{AdapterTee, `{"first":{"adapter":"file","config":{"filename":"a.log","level":9}},"second":{"adapter":"console"}}`, false},
+		{AdapterUnixSocket, `{"path":"` + sock + `"}`, true},
+		{AdapterUnixSocket, `{"path":"` + filepath.Join(dir, "none.sock") + `"}`, false},
+		{AdapterUnixSocket, `{"path":"` + filepath.Join(dir, "none.sock") + `","buffer_size":10}`, true},
+		{AdapterUnixSocket, `{}`, false},
+		{AdapterUnixSocket, `{"path":"` + sock + `","framing":"xml"}`, false},
+		{AdapterUnixSocket, `{"path":"` + sock + `","drop":"random"}`, false},
+		{AdapterDB, `{"table":"app_logs"}`, true},
+		{AdapterDB, `{"table":"logs; DROP TABLE users"}`, false},
+		{AdapterDB, `{"driver":"logsfake","driver_dsn":"x"}`, true},
+		{AdapterDB, `{"driver":"nosuchdriver","driver_dsn":"x"}`, false},
+		{AdapterCloudWatch, `{"group":"app","stream":"web1"}`, true},
+		{AdapterCloudWatch, `{"group":"app"}`, false},
+		{AdapterStackdriver, `{"log_name":"app"}`, true},
+		{AdapterStackdriver, `{}`, false},
+		{"nope", `{}`, false},
+	}
+	for _, tt := range tests {
+		err := ValidateConfig(tt.adapter, tt.config)
+		if (err == nil) != tt.valid {
+			t.Errorf("ValidateConfig(%q, %s) = %v, want valid %v", tt.adapter, tt.config, err, tt.valid)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "default.log")); !os.IsNotExist(err) {
+		t.Errorf("validation created default.log: %v", err)
+	}
+	for _, name := range []string{"app.log", "tee.log", "a.log"} {
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			t.Errorf("validation created %s", name)
+		}
+	}
+}
+
+func TestValidateConfigWithoutClients(t *testing.T) {
+	SetDB(nil)
+	SetCloudWatchClient(nil)
+	SetStackdriverClient(nil)
+	for _, adapter := range []string{AdapterDB, AdapterCloudWatch, AdapterStackdriver} {
+		if err := ValidateConfig(adapter, `{"group":"a","stream":"b","log_name":"c"}`); err == nil {
+			t.Errorf("%s validated without a client", adapter)
+		}
+	}
+}
+
+// 检查 tee 里 append 为 false 的文件时不能清空已有的文件
+func TestValidateTeeKeepsFiles(t *testing.T) {
+	inTempDir(t)
+	if err := ioutil.WriteFile("app.log", []byte("keep me\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := `{"first":{"adapter":"file","level":3,"config":{"filename":"app.log","append":false}},"second":{"adapter":"console"}}`
+	if err := ValidateConfig(AdapterTee, config); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile("app.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "keep me\n" {
+		t.Errorf("app.log changed to %q", b)
+	}
+	if _, err := os.Stat("default.log"); !os.IsNotExist(err) {
+		t.Error("validation created default.log")
+	}
+}
+
+// 没有检查函数的 adapter 通过 Init 检查
+func TestValidateConfigRegistered(t *testing.T) {
+	Register("validatetest", func() Logger { return NewWriterAdapter(ioutil.Discard, LevelDebug) })
+	defer delete(adapters, "validatetest")
+	for level, valid := range map[int]bool{LevelError: true, LevelDebug: true, 5: false} {
+		err := ValidateConfig("validatetest#x", `{"level":`+strconv.Itoa(level)+`}`)
+		if (err == nil) != valid {
+			t.Errorf("level %d: %v", level, err)
+		}
+	}
+}
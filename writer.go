@@ -0,0 +1,104 @@
+package logs
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// ioWriter 把任意 io.Writer 包装成 Logger，不经过 adapters 注册
+type ioWriter struct {
+	colorTheme
+	lg             *logWriter
+	Level          adapterLevel `json:"level"`
+	Colorful       bool         `json:"color"`
+	NoNewline      bool         `json:"no_newline"`
+	TimeFormat     string       `json:"time_format"`
+	TimeZone       string       `json:"time_zone"`
+	SecondTimeZone string       `json:"second_time_zone"`
+	JSON           bool         `json:"json"`        // 每行输出一个 JSON 对象
+	EscapeHTML     bool         `json:"escape_html"` // JSON 输出时是否把 <>& 转义为 \u003c 这种形式
+}
+
+// NewWriterAdapter 把 w 包装成一个 Logger，如内存 buffer、管道或自定义的输出
+func NewWriterAdapter(w io.Writer, level int) Logger {
+	return &ioWriter{
+		lg:    newLogWriter(w),
+		Level: adapterLevel(level),
+	}
+}
+
+// Init 配置格式同 console，如 '{"level":3,"color":false,"no_newline":true}'
+func (w *ioWriter) Init(jsonConfig string) error {
+	if len(jsonConfig) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(jsonConfig), w); err != nil {
+		return err
+	}
+	zones, err := loadTimeZones(w.TimeZone, w.SecondTimeZone)
+	if err != nil {
+		return err
+	}
+	w.lg.zones = zones
+	w.lg.noNewline = w.NoNewline
+	w.lg.timeFormat = w.TimeFormat
+	return nil
+}
+
+// WriteMsg write message to the underlying writer.
+func (w *ioWriter) WriteMsg(when time.Time, msg string, level int) error {
+	return w.writeText(when, 0, msg, level)
+}
+
+func (w *ioWriter) writeText(when time.Time, precision time.Duration, msg string, level int) error {
+	if level > w.Level.get() {
+		return nil
+	}
+	if w.Colorful {
+		msg = w.palette().colorLevelPrefix(msg, level)
+	}
+	_, err := w.lg.writeLine(when, precision, msg, nil)
+	return err
+}
+
+// WriteRecord write record as a JSON line when json is enabled, otherwise same as WriteMsg.
+func (w *ioWriter) WriteRecord(r *Record) error {
+	if !w.JSON {
+		return w.writeText(r.When, r.precision, r.String(), r.Level)
+	}
+	if r.Level > w.Level.get() {
+		return nil
+	}
+	_, err := w.lg.writeJSON(r, w.EscapeHTML)
+	return err
+}
+
+// WriteRaw write the bytes produced by the AppLogger's Formatter.
+func (w *ioWriter) WriteRaw(p []byte, level int) error {
+	if level > w.Level.get() {
+		return nil
+	}
+	_, err := w.lg.writeRaw(p)
+	return err
+}
+
+// GetLevel return the level of this writer.
+func (w *ioWriter) GetLevel() int {
+	return w.Level.get()
+}
+
+// SetLevel set the level of this writer.
+func (w *ioWriter) SetLevel(level int) {
+	w.Level.set(level)
+}
+
+// Destroy implementing method. empty.
+func (w *ioWriter) Destroy() {
+
+}
+
+// Flush implementing method. empty.
+func (w *ioWriter) Flush() {
+
+}
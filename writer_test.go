@@ -0,0 +1,105 @@
+package logs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAddWriter(t *testing.T) {
+	var buf bytes.Buffer
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	defer al.Close()
+	if err := al.AddWriter("buf", &buf, LevelInfo); err != nil {
+		t.Fatal(err)
+	}
+	if err := al.AddWriter("buf", &buf, LevelInfo); err == nil {
+		t.Error("added the same name twice")
+	}
+	al.Info("to the buffer %d", 1)
+	al.Debug("below the writer level")
+
+	out := buf.String()
+	if !strings.Contains(out, "[I]") || !strings.HasSuffix(out, "to the buffer 1\n") || strings.Contains(out, "below") {
+		t.Errorf("got %q", out)
+	}
+}
+
+func TestWriterAdapterNoNewline(t *testing.T) {
+	var buf bytes.Buffer
+	lg := NewWriterAdapter(&buf, LevelDebug)
+	if err := lg.Init(`{"no_newline":true}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := lg.WriteMsg(time.Now(), "[I] one", LevelInfo); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.HasSuffix(out, "[I] one") {
+		t.Errorf("got %q", out)
+	}
+}
+
+func TestPerAdapterTimeFormat(t *testing.T) {
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	defer al.Close()
+	when := time.Date(2024, 3, 9, 14, 5, 6, 789000000, time.UTC)
+	al.SetTimeFunc(func(time.Time) time.Time { return when })
+	var def, iso, short bytes.Buffer
+	for name, w := range map[string]*bytes.Buffer{"def": &def, "iso": &iso, "short": &short} {
+		lg := NewWriterAdapter(w, LevelDebug)
+		switch name {
+		case "iso":
+			lg.Init(`{"time_format":"2006-01-02T15:04:05Z07:00"}`)
+		case "short":
+			lg.Init(`{"time_format":"15:04"}`)
+		}
+		al.AddAdapter(name, lg)
+	}
+	al.Info("hello")
+
+	want := map[*bytes.Buffer]string{
+		&def:   "2024-03-09 14:05:06.789  [I]  hello\n",
+		&iso:   "2024-03-09T14:05:06Z  [I]  hello\n",
+		&short: "14:05  [I]  hello\n",
+	}
+	for buf, w := range want {
+		if buf.String() != w {
+			t.Errorf("got %q, want %q", buf.String(), w)
+		}
+	}
+}
+
+func TestSetTimeFuncRounding(t *testing.T) {
+	al := NewAppLogger()
+	al.RemoveLogger(AdapterConsole)
+	defer al.Close()
+	var buf bytes.Buffer
+	al.AddWriter("buf", &buf, LevelDebug)
+	al.SetTimeFunc(func(t time.Time) time.Time { return t.Round(time.Second) })
+
+	base := time.Date(2024, 3, 9, 14, 5, 6, 0, time.UTC)
+	al.LogBatch([]Entry{
+		{Level: LevelInfo, When: base.Add(400 * time.Millisecond), Msg: "down"},
+		{Level: LevelInfo, When: base.Add(600 * time.Millisecond), Msg: "up"},
+	})
+	want := "2024-03-09 14:05:06.000  [I]  down\n2024-03-09 14:05:07.000  [I]  up\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	al.Info("now")
+	if got := buf.String(); !strings.Contains(got, ".000  [I]  now") {
+		t.Errorf("got %q", got)
+	}
+
+	buf.Reset()
+	al.SetTimeFunc(nil)
+	al.LogBatch([]Entry{{Level: LevelInfo, When: base.Add(400 * time.Millisecond), Msg: "unchanged"}})
+	if got := buf.String(); got != "2024-03-09 14:05:06.400  [I]  unchanged\n" {
+		t.Errorf("got %q after removing the func", got)
+	}
+}